@@ -59,7 +59,7 @@ func TestPOSTDownHandlerChangeStatus(t *testing.T) {
 		t.Errorf("Did not get a 200.")
 	}
 
-	if len(health.CheckStatus()) != 1 {
+	if failing(health.CheckStatus()) != 1 {
 		t.Errorf("DownHandler didn't add an error check.")
 	}
 }
@@ -80,7 +80,18 @@ func TestPOSTUpHandlerChangeStatus(t *testing.T) {
 		t.Errorf("Did not get a 200.")
 	}
 
-	if len(health.CheckStatus()) != 0 {
+	if failing(health.CheckStatus()) != 0 {
 		t.Errorf("UpHandler didn't remove the error check.")
 	}
 }
+
+// failing returns the number of failing checks in status.
+func failing(status map[string]health.CheckResult) int {
+	n := 0
+	for _, r := range status {
+		if r.Err != nil {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,137 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// cachedResult is implemented by checks serving a cached result.
+type cachedResult interface {
+	// resultTime returns when the cached result was produced.
+	resultTime() time.Time
+}
+
+// StaleWhileRevalidateChecker returns a Checker serving the last result of
+// check immediately. When that result is older than fresh, a refresh is
+// started in the background, concurrent refreshes being coalesced into a
+// single run of check. Only the very first evaluation waits for check to
+// complete. The age of the served result is part of the Report.
+func StaleWhileRevalidateChecker(check Checker, fresh time.Duration) Checker {
+	return &staleWhileRevalidate{check: check, fresh: fresh}
+}
+
+// staleWhileRevalidate is the Checker returned by
+// StaleWhileRevalidateChecker.
+type staleWhileRevalidate struct {
+	check Checker
+	fresh time.Duration
+
+	mu         sync.Mutex
+	err        error
+	at         time.Time     // when err was produced, zero until then
+	refreshing chan struct{} // closed when the refresh in progress completes
+}
+
+// Check implements the Checker interface.
+func (s *staleWhileRevalidate) Check() error {
+	s.mu.Lock()
+	if s.at.IsZero() {
+		refreshing := s.refresh()
+		s.mu.Unlock()
+		<-refreshing
+		s.mu.Lock()
+	} else if time.Since(s.at) > s.fresh {
+		s.refresh()
+	}
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+// resultTime implements the cachedResult interface.
+func (s *staleWhileRevalidate) resultTime() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.at
+}
+
+// refresh starts refreshing the result unless a refresh is already in
+// progress, and returns a channel closed once the refresh completes. The
+// caller must hold s.mu.
+func (s *staleWhileRevalidate) refresh() <-chan struct{} {
+	if s.refreshing != nil {
+		return s.refreshing
+	}
+
+	refreshing := make(chan struct{})
+	s.refreshing = refreshing
+	go func() {
+		err := evaluateSafely(context.Background(), s.check)
+
+		s.mu.Lock()
+		s.err, s.at = err, time.Now()
+		s.refreshing = nil
+		s.mu.Unlock()
+		close(refreshing)
+	}()
+
+	return refreshing
+}
+
+// CachingChecker returns a Checker running check at most once every ttl and
+// serving its cached result otherwise. Unlike StaleWhileRevalidateChecker, an
+// expired result is never served: the evaluation following its expiry waits
+// for a fresh run, concurrent evaluations being coalesced into a single run of
+// check. The age of the served result is part of the Report.
+func CachingChecker(check Checker, ttl time.Duration) Checker {
+	return &cachingChecker{check: check, ttl: ttl}
+}
+
+// cachingChecker is the Checker returned by CachingChecker.
+type cachingChecker struct {
+	check Checker
+	ttl   time.Duration
+
+	mu      sync.Mutex
+	err     error
+	at      time.Time     // when err was produced, zero until then
+	running chan struct{} // closed when the run in progress completes
+}
+
+// Check implements the Checker interface.
+func (c *cachingChecker) Check() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.at.IsZero() && time.Since(c.at) < c.ttl {
+		return c.err
+	}
+	if running := c.running; running != nil {
+		// the result of the run in progress is fresh by definition
+		c.mu.Unlock()
+		<-running
+		c.mu.Lock()
+		return c.err
+	}
+
+	running := make(chan struct{})
+	c.running = running
+	c.mu.Unlock()
+	err := evaluateSafely(context.Background(), c.check)
+	c.mu.Lock()
+
+	c.err, c.at = err, time.Now()
+	c.running = nil
+	close(running)
+	return c.err
+}
+
+// resultTime implements the cachedResult interface.
+func (c *cachingChecker) resultTime() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.at
+}
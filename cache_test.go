@@ -0,0 +1,94 @@
+package health
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestStaleWhileRevalidateChecker ensures that a stale result is served while
+// a single refresh runs in the background.
+func TestStaleWhileRevalidateChecker(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	checker := StaleWhileRevalidateChecker(CheckFunc(func() error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return nil
+		}
+		<-release
+		return errors.New("down")
+	}), 10*time.Millisecond)
+
+	if err := checker.Check(); err != nil {
+		t.Fatalf("first result was expected to pass, error:%v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		if err := checker.Check(); err != nil {
+			t.Fatalf("stale result was expected to be served during the refresh, error:%v", err)
+		}
+	}
+
+	close(release)
+	for i := 0; checker.Check() == nil; i++ {
+		if i == 100 {
+			t.Fatalf("refreshed result was expected to be served")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("concurrent refreshes were expected to be coalesced: %d calls != 2", n)
+	}
+
+	registry := NewRegistry()
+	registry.Register("swr", StaleWhileRevalidateChecker(CheckFunc(func() error {
+		return nil
+	}), time.Hour))
+	registry.Report()
+	time.Sleep(5 * time.Millisecond)
+	if cr := registry.Report().Checks["swr"]; cr.AgeMs < 5 {
+		t.Errorf("age of the cached result was expected in the report: %+v", cr)
+	}
+}
+
+// TestCachingChecker ensures that concurrent evaluations are coalesced into a
+// single run of the check, whose result is served until it expires.
+func TestCachingChecker(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	checker := CachingChecker(CheckFunc(func() error {
+		calls.Add(1)
+		<-release
+		return errors.New("down")
+	}), 20*time.Millisecond)
+
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			errs <- checker.Check()
+		}()
+	}
+	time.Sleep(5 * time.Millisecond)
+	close(release)
+	for i := 0; i < 5; i++ {
+		if err := <-errs; err == nil {
+			t.Errorf("the result of the check was expected")
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("concurrent evaluations were expected to run the check once, ran %d times", n)
+	}
+
+	checker.Check()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("the cached result was expected to be served, ran %d times", n)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	checker.Check()
+	if n := calls.Load(); n != 2 {
+		t.Errorf("an expired result was expected to be refreshed, ran %d times", n)
+	}
+}
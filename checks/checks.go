@@ -1,38 +1,200 @@
 package checks
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"net"
 	"net/http"
 	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/docker/distribution/health"
 )
 
 // FileChecker checks the existence of a file and returns an error
-// if the file exists.
+// if the file exists. This implements the "down file" pattern, where creating
+// the file takes the service out of rotation. Failing to determine whether the
+// file exists, e.g. for lack of permissions, is reported as an error as well.
 func FileChecker(f string) health.Checker {
 	return health.CheckFunc(func() error {
-		if _, err := os.Stat(f); err == nil {
+		_, err := os.Stat(f)
+		if err == nil {
 			return errors.New("file exists")
 		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return errors.New("error checking file " + f + ": " + err.Error())
+		}
+		return nil
+	})
+}
+
+// FileExistsChecker is the reverse of FileChecker: it returns an error if the
+// file does not exist or its existence cannot be determined.
+func FileExistsChecker(f string) health.Checker {
+	return health.CheckFunc(func() error {
+		_, err := os.Stat(f)
+		if errors.Is(err, fs.ErrNotExist) {
+			return errors.New("file " + f + " does not exist")
+		}
+		if err != nil {
+			return errors.New("error checking file " + f + ": " + err.Error())
+		}
+		return nil
+	})
+}
+
+// DiskSpaceChecker fails when the space available to unprivileged users on the
+// filesystem containing path drops below minFreeBytes, before writes start
+// failing. As the check stats the filesystem, it is best run as a periodic
+// check. It always fails on platforms where free space cannot be determined,
+// such as Windows.
+func DiskSpaceChecker(path string, minFreeBytes uint64) health.Checker {
+	return health.CheckFunc(func() error {
+		free, err := freeSpace(path)
+		if err != nil {
+			return errors.New("error checking free space of " + path + ": " + err.Error())
+		}
+		if free < minFreeBytes {
+			return errors.New(strconv.FormatUint(free, 10) + " bytes free on the filesystem of " + path +
+				", expected at least " + strconv.FormatUint(minFreeBytes, 10))
+		}
+		return nil
+	})
+}
+
+// MemoryChecker fails when the heap allocated by the process, as reported by
+// the HeapAlloc field of runtime.MemStats, exceeds maxHeapBytes, such as to
+// catch leaks in long-running services. The error reports the current usage.
+//
+// Reading the memory statistics stops the world, so the checker must not run
+// on every evaluation: wrap it in health.PeriodicChecker or
+// health.CachingChecker.
+func MemoryChecker(maxHeapBytes uint64) health.Checker {
+	return health.CheckFunc(func() error {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		if stats.HeapAlloc > maxHeapBytes {
+			return errors.New("heap allocation of " + strconv.FormatUint(stats.HeapAlloc, 10) +
+				" bytes exceeds " + strconv.FormatUint(maxHeapBytes, 10))
+		}
 		return nil
 	})
 }
 
+// HTTPOption configures optional behaviour of an HTTPChecker.
+type HTTPOption func(*httpCheckerConfig)
+
+// httpCheckerConfig holds the optional settings applied by HTTPOptions.
+type httpCheckerConfig struct {
+	method         string
+	body           []byte
+	contentType    string
+	headerMatchers []headerMatcher
+}
+
+// headerMatcher verifies the value of a single response header.
+type headerMatcher struct {
+	name  string
+	match func(value string) bool
+}
+
+// WithExpectedHeader makes the HTTPChecker fail unless the response carries
+// the header name with exactly the given value. This catches misrouted
+// traffic that still returns the expected status code.
+func WithExpectedHeader(name, value string) HTTPOption {
+	return withHeaderMatcher(name, func(v string) bool {
+		return v == value
+	})
+}
+
+// WithExpectedHeaderPrefix makes the HTTPChecker fail unless the response
+// carries the header name with a value starting with prefix.
+func WithExpectedHeaderPrefix(name, prefix string) HTTPOption {
+	return withHeaderMatcher(name, func(v string) bool {
+		return strings.HasPrefix(v, prefix)
+	})
+}
+
+// WithExpectedHeaderRegexp makes the HTTPChecker fail unless the response
+// carries the header name with a value matching re.
+func WithExpectedHeaderRegexp(name string, re *regexp.Regexp) HTTPOption {
+	return withHeaderMatcher(name, re.MatchString)
+}
+
+// WithMethod sets the method of the requests made by the HTTPChecker, HEAD
+// by default.
+func WithMethod(method string) HTTPOption {
+	return func(c *httpCheckerConfig) {
+		c.method = method
+	}
+}
+
+// WithBody sets the body sent with every request made by the HTTPChecker.
+// The body is replayed from the start for each request, including when the
+// request is retried or redirected.
+func WithBody(body []byte) HTTPOption {
+	return func(c *httpCheckerConfig) {
+		c.body = body
+	}
+}
+
+// WithContentType sets the Content-Type header of the requests made by the
+// HTTPChecker.
+func WithContentType(contentType string) HTTPOption {
+	return func(c *httpCheckerConfig) {
+		c.contentType = contentType
+	}
+}
+
+func withHeaderMatcher(name string, match func(string) bool) HTTPOption {
+	return func(c *httpCheckerConfig) {
+		c.headerMatchers = append(c.headerMatchers, headerMatcher{name: name, match: match})
+	}
+}
+
+// maxDrainedBody is the largest part of a response body read by HTTPChecker
+// so that its connection can be reused.
+const maxDrainedBody = 64 << 10
+
 // HTTPChecker does a HEAD request and verifies that the HTTP status code
-// returned matches statusCode.
-func HTTPChecker(r string, statusCode int, timeout time.Duration, headers http.Header) health.Checker {
+// returned matches statusCode. The request and additional expectations on the
+// response can be customized with HTTPOptions, e.g. WithMethod(http.MethodGet)
+// for endpoints not supporting HEAD. The response body is drained and closed.
+func HTTPChecker(r string, statusCode int, timeout time.Duration, headers http.Header, opts ...HTTPOption) health.Checker {
+	config := httpCheckerConfig{method: "HEAD"}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
 	return health.CheckFunc(func() error {
 		client := http.Client{
 			Timeout: timeout,
 		}
-		req, err := http.NewRequest("HEAD", r, nil)
+		var body io.Reader
+		if config.body != nil {
+			// a bytes.Reader also lets the request set GetBody for replays
+			body = bytes.NewReader(config.body)
+		}
+		req, err := http.NewRequest(config.method, r, body)
 		if err != nil {
 			return errors.New("error creating request: " + r)
 		}
+		if config.contentType != "" {
+			req.Header.Set("Content-Type", config.contentType)
+		}
 		for headerName, headerValues := range headers {
 			for _, headerValue := range headerValues {
 				req.Header.Add(headerName, headerValue)
@@ -42,21 +204,405 @@ func HTTPChecker(r string, statusCode int, timeout time.Duration, headers http.H
 		if err != nil {
 			return errors.New("error while checking: " + r)
 		}
+		defer func() {
+			// drain the body, within reason, so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(response.Body, maxDrainedBody))
+			response.Body.Close()
+		}()
 		if response.StatusCode != statusCode {
 			return errors.New("downstream service returned unexpected status: " + strconv.Itoa(response.StatusCode))
 		}
+		for _, m := range config.headerMatchers {
+			values := response.Header.Values(m.name)
+			if len(values) == 0 {
+				return errors.New("downstream service response is missing header: " + m.name)
+			}
+			if !m.match(values[0]) {
+				return errors.New("downstream service returned unexpected " + m.name + " header: " + values[0])
+			}
+		}
 		return nil
 	})
 }
 
-// TCPChecker attempts to open a TCP connection.
+// TCPChecker attempts to open a TCP connection to addr within timeout,
+// closing it right away on success.
 func TCPChecker(addr string, timeout time.Duration) health.Checker {
 	return health.CheckFunc(func() error {
 		conn, err := net.DialTimeout("tcp", addr, timeout)
 		if err != nil {
-			return errors.New("connection to " + addr + " failed")
+			return errors.New("connection to " + addr + " failed: " + err.Error())
 		}
 		conn.Close()
 		return nil
 	})
 }
+
+// DBChecker pings a database, such as a *sql.DB, through its PingContext
+// method, bounded by the deadline of the evaluation. It accepts any driver or
+// pool exposing PingContext. Wrap it with health.PeriodicChecker to keep the
+// database from being pinged on every status request.
+func DBChecker(pinger interface{ PingContext(context.Context) error }) health.Checker {
+	return health.CheckFuncContext(func(ctx context.Context) error {
+		if err := pinger.PingContext(ctx); err != nil {
+			return errors.New("database ping failed: " + err.Error())
+		}
+		return nil
+	})
+}
+
+// ErrRemoteUnhealthy is wrapped by the errors of RemoteHealthChecker when the
+// remote endpoint reports itself unhealthy, as opposed to being unreachable or
+// returning an invalid response.
+var ErrRemoteUnhealthy = errors.New("downstream reported unhealthy")
+
+// maxRemoteStatusBody is the largest response body parsed by
+// RemoteHealthChecker.
+const maxRemoteStatusBody = 1 << 20
+
+// RemoteHealthChecker GETs the health status endpoint of another service, such
+// as its /debug/health, and parses the JSON body served by the status handlers
+// of this package, so that a gateway can aggregate the health of the services
+// it fronts. It fails with an error wrapping ErrRemoteUnhealthy and listing the
+// failing checks of the service when it reports itself unhealthy, and with a
+// warning, see health.Warnf, when it reports itself degraded. Failures to
+// reach the service or to parse its response are reported with other errors.
+func RemoteHealthChecker(url string, timeout time.Duration) health.Checker {
+	return health.CheckFuncContext(func(ctx context.Context) error {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return errors.New("error creating request: " + url + ": " + err.Error())
+		}
+		req.Header.Set("Accept", "application/json")
+		response, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return errors.New("health endpoint " + url + " unreachable: " + err.Error())
+		}
+		defer func() {
+			io.Copy(io.Discard, io.LimitReader(response.Body, maxDrainedBody))
+			response.Body.Close()
+		}()
+
+		var status struct {
+			Status   string            `json:"status"`
+			Checks   map[string]string `json:"checks"`
+			Warnings map[string]string `json:"warnings"`
+		}
+		err = json.NewDecoder(io.LimitReader(response.Body, maxRemoteStatusBody)).Decode(&status)
+		if err != nil || status.Status == "" {
+			return errors.New("health endpoint " + url + " returned an invalid health status with status code " +
+				strconv.Itoa(response.StatusCode))
+		}
+
+		switch status.Status {
+		case health.StatusHealthy:
+			return nil
+		case health.StatusDegraded:
+			return health.Warnf("%s reported degraded%s", url, remoteFailures(status.Warnings))
+		default:
+			return fmt.Errorf("%s %w%s", url, ErrRemoteUnhealthy, remoteFailures(status.Checks))
+		}
+	})
+}
+
+// remoteFailures formats the failing checks reported by a remote health
+// endpoint, sorted by name, as a suffix of the error, which is empty if the
+// endpoint hides them.
+func remoteFailures(checks map[string]string) string {
+	if len(checks) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		names[i] = name + ": " + checks[name]
+	}
+	return ": " + strings.Join(names, "; ")
+}
+
+// CommandChecker runs the command name with args, such as an existing
+// Nagios-style check script, and fails unless it exits with status 0,
+// including what the command wrote to its standard error in the error. The
+// command is killed if it runs for longer than timeout, when positive, or
+// past the deadline of the evaluation.
+func CommandChecker(name string, args []string, timeout time.Duration) health.Checker {
+	return health.CheckFuncContext(func(ctx context.Context) error {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Stderr = &stderr
+		// do not wait forever for children of the command holding stderr
+		cmd.WaitDelay = time.Second
+		err := cmd.Run()
+		if ctx.Err() != nil {
+			return errors.New("command " + name + " did not complete: " + ctx.Err().Error())
+		}
+		if err != nil {
+			msg := "command " + name + " failed: " + err.Error()
+			if out := strings.TrimSpace(stderr.String()); out != "" {
+				msg += ": " + out
+			}
+			return errors.New(msg)
+		}
+		return nil
+	})
+}
+
+// DNSChecker resolves host with the system resolver and fails when the lookup
+// errors, does not complete within timeout, or returns no address. Wrapping it
+// in a health.PeriodicThresholdChecker avoids flapping on transient failures.
+func DNSChecker(host string, timeout time.Duration) health.Checker {
+	return dnsChecker(net.DefaultResolver, host, timeout, "")
+}
+
+// dnsChecker returns a checker resolving host with resolver, describing the
+// resolver in its errors if desc is not empty.
+func dnsChecker(resolver *net.Resolver, host string, timeout time.Duration, desc string) health.Checker {
+	if desc != "" {
+		desc = " with " + desc
+	}
+	return health.CheckFunc(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		addrs, err := resolver.LookupHost(ctx, host)
+		if err != nil {
+			return errors.New("resolving " + host + desc + " failed: " + err.Error())
+		}
+		if len(addrs) == 0 {
+			return errors.New("resolving " + host + desc + " returned no address")
+		}
+		return nil
+	})
+}
+
+// DNSServerChecker resolves host through the DNS server listening at
+// resolverAddr, a "host:port" address, and fails like DNSChecker. Unlike a
+// lookup through the system resolver, this isolates failures of that
+// particular server.
+func DNSServerChecker(resolverAddr, host string, timeout time.Duration) health.Checker {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, resolverAddr)
+		},
+	}
+	return dnsChecker(resolver, host, timeout, resolverAddr)
+}
+
+// ReplicationLagChecker fails when the replication lag reported by lag exceeds
+// maxLag. An error returned by lag is reported as a failure as well.
+func ReplicationLagChecker(lag func() (time.Duration, error), maxLag time.Duration) health.Checker {
+	return health.CheckFunc(func() error {
+		l, err := lag()
+		if err != nil {
+			return errors.New("error probing replication lag: " + err.Error())
+		}
+		if l > maxLag {
+			return errors.New("replication lag " + l.String() + " exceeds " + maxLag.String())
+		}
+		return nil
+	})
+}
+
+// ConsumerProgressChecker fails when the rate at which a consumer processes
+// messages, computed from the processed counter over the last window, falls
+// below minRatePerSec. This catches consumers that stay connected but no
+// longer make progress. The counter is sampled on every check, so the checker
+// should be checked more often than every window, e.g. as a periodic check.
+// It passes until a sample at least window old is available, and again after
+// the counter is reset.
+func ConsumerProgressChecker(processed func() uint64, minRatePerSec float64, window time.Duration) health.Checker {
+	type sample struct {
+		at    time.Time
+		count uint64
+	}
+	var (
+		mu      sync.Mutex
+		samples []sample // oldest first
+	)
+	return health.CheckFunc(func() error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		now := sample{at: time.Now(), count: processed()}
+		if len(samples) > 0 && now.count < samples[len(samples)-1].count {
+			samples = nil // the counter was reset
+		}
+		samples = append(samples, now)
+
+		// keep the newest sample at least window old as the base of the rate
+		base := -1
+		for i, s := range samples {
+			if now.at.Sub(s.at) >= window {
+				base = i
+			}
+		}
+		if base < 0 {
+			return nil
+		}
+		samples = samples[base:]
+
+		elapsed := now.at.Sub(samples[0].at)
+		rate := float64(now.count-samples[0].count) / elapsed.Seconds()
+		if rate < minRatePerSec {
+			return errors.New("processing rate " + strconv.FormatFloat(rate, 'f', 2, 64) + "/s over " +
+				elapsed.Round(time.Millisecond).String() + " below " + strconv.FormatFloat(minRatePerSec, 'f', 2, 64) + "/s")
+		}
+		return nil
+	})
+}
+
+// MembershipChecker fails when the number of cluster peers reported by count
+// drops below min.
+func MembershipChecker(count func() int, min int) health.Checker {
+	return health.CheckFunc(func() error {
+		if n := count(); n < min {
+			return errors.New("cluster has " + strconv.Itoa(n) + " peers, expected at least " + strconv.Itoa(min))
+		}
+		return nil
+	})
+}
+
+// PortFreeChecker fails if the TCP address addr cannot be bound, typically
+// because another process, such as a zombie instance, is already listening on
+// it. It is the inverse of TCPChecker and is meant as a startup preflight.
+// The result is inherently racy: the port may be taken between the check and
+// the actual bind, so a passing check does not guarantee the bind succeeds.
+func PortFreeChecker(addr string) health.Checker {
+	return health.CheckFunc(func() error {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return errors.New("address " + addr + " is not free: " + err.Error())
+		}
+		l.Close()
+		return nil
+	})
+}
+
+// LicenseChecker fails when the license expiring at the time returned by
+// expiry has expired or will expire within warnBefore. A license about to
+// expire is reported with health.SeverityWarning.
+func LicenseChecker(expiry func() time.Time, warnBefore time.Duration) health.Checker {
+	return health.CheckFunc(func() error {
+		e := expiry()
+		if !time.Now().Before(e) {
+			return errors.New("license expired on " + e.Format(time.RFC3339))
+		}
+		if time.Until(e) <= warnBefore {
+			return &health.StatusError{
+				Severity: health.SeverityWarning,
+				Err:      errors.New("license expires on " + e.Format(time.RFC3339)),
+			}
+		}
+		return nil
+	})
+}
+
+// TrafficChecker fails when the last request served, as reported by
+// lastRequest, is older than maxIdle. This catches services that are up but
+// no longer receive any traffic. A RequestClock can feed lastRequest.
+func TrafficChecker(lastRequest func() time.Time, maxIdle time.Duration) health.Checker {
+	return health.CheckFunc(func() error {
+		last := lastRequest()
+		if last.IsZero() {
+			return errors.New("no request served yet")
+		}
+		if idle := time.Since(last); idle > maxIdle {
+			return errors.New("no request served for " + idle.Round(time.Second).String())
+		}
+		return nil
+	})
+}
+
+// RequestClock records the time of the last request served by a handler. It
+// is safe for concurrent use and cheap enough to sit in the request path.
+type RequestClock struct {
+	last atomic.Int64 // Unix nanoseconds
+}
+
+// Touch records the current time as the time of the last request.
+func (c *RequestClock) Touch() {
+	c.last.Store(time.Now().UnixNano())
+}
+
+// Last returns the time of the last request, or the zero time if none was
+// recorded.
+func (c *RequestClock) Last() time.Time {
+	n := c.last.Load()
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// Handler returns a handler recording every request before passing it through
+// to handler.
+func (c *RequestClock) Handler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Touch()
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// SidecarChecker fails until a sidecar proxy, such as a service mesh proxy,
+// accepts TCP connections on its admin address adminAddr within timeout.
+// Registering it keeps the service out of rotation, e.g. through
+// health.Handler, while traffic could not yet be routed through the proxy.
+func SidecarChecker(adminAddr string, timeout time.Duration) health.Checker {
+	check := TCPChecker(adminAddr, timeout)
+	return health.CheckFunc(func() error {
+		if err := check.Check(); err != nil {
+			return errors.New("sidecar proxy is not reachable: " + err.Error())
+		}
+		return nil
+	})
+}
+
+// SecretChecker fails when the secret returned by get cannot be retrieved or
+// is empty or blank. The value of the secret is never included in the error.
+func SecretChecker(get func() (string, error)) health.Checker {
+	return health.CheckFunc(func() error {
+		secret, err := get()
+		if err != nil {
+			return errors.New("error retrieving secret: " + err.Error())
+		}
+		if strings.TrimSpace(secret) == "" {
+			return errors.New("secret is empty")
+		}
+		return nil
+	})
+}
+
+// ReadinessMatrixChecker fails until every step in steps reports completion,
+// listing the steps that remain in its error. This combines several
+// independent startup prerequisites, such as migrations or cache warm-up,
+// into a single readiness check.
+func ReadinessMatrixChecker(steps map[string]func() bool) health.Checker {
+	return health.CheckFunc(func() error {
+		var remaining []string
+		for name, done := range steps {
+			if !done() {
+				remaining = append(remaining, name)
+			}
+		}
+		if len(remaining) == 0 {
+			return nil
+		}
+		sort.Strings(remaining)
+		return errors.New("waiting for " + strconv.Itoa(len(remaining)) + " of " + strconv.Itoa(len(steps)) + " steps: " + strings.Join(remaining, ", "))
+	})
+}
@@ -1,7 +1,20 @@
 package checks
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/docker/distribution/health"
 )
 
 func TestFileChecker(t *testing.T) {
@@ -14,6 +27,33 @@ func TestFileChecker(t *testing.T) {
 	}
 }
 
+func TestFileExistsChecker(t *testing.T) {
+	if err := FileExistsChecker("/tmp").Check(); err != nil {
+		t.Errorf("/tmp was expected as exists, error:%v", err)
+	}
+
+	if err := FileExistsChecker("NoSuchFileFromMoon").Check(); err == nil {
+		t.Errorf("NoSuchFileFromMoon was expected as not exists")
+	}
+}
+
+func TestFileCheckerStatError(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "file")
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	f.Close()
+
+	// a path under a regular file can be neither found nor ruled out
+	path := f.Name() + "/child"
+	if err := FileChecker(path).Check(); err == nil || !strings.Contains(err.Error(), "error checking file") {
+		t.Errorf("stat failure was expected to be reported, error:%v", err)
+	}
+	if err := FileExistsChecker(path).Check(); err == nil || !strings.Contains(err.Error(), "error checking file") {
+		t.Errorf("stat failure was expected to be reported, error:%v", err)
+	}
+}
+
 func TestHTTPChecker(t *testing.T) {
 	if err := HTTPChecker("https://www.google.cybertron", 200, 0, nil).Check(); err == nil {
 		t.Errorf("Google on Cybertron was expected as not exists")
@@ -23,3 +63,413 @@ func TestHTTPChecker(t *testing.T) {
 		t.Errorf("Google at Portugal was expected as exists, error:%v", err)
 	}
 }
+
+func TestHTTPCheckerExpectedHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Backend", "primary-1")
+	}))
+	defer server.Close()
+
+	if err := HTTPChecker(server.URL, 200, 0, nil, WithExpectedHeader("X-Backend", "primary-1")).Check(); err != nil {
+		t.Errorf("matching header was expected to pass, error:%v", err)
+	}
+
+	if err := HTTPChecker(server.URL, 200, 0, nil, WithExpectedHeader("X-Backend", "secondary")).Check(); err == nil {
+		t.Errorf("wrong header value was expected to fail")
+	}
+
+	if err := HTTPChecker(server.URL, 200, 0, nil, WithExpectedHeader("X-Missing", "primary")).Check(); err == nil {
+		t.Errorf("missing header was expected to fail")
+	}
+
+	if err := HTTPChecker(server.URL, 200, 0, nil, WithExpectedHeaderPrefix("X-Backend", "primary")).Check(); err != nil {
+		t.Errorf("header prefix was expected to match, error:%v", err)
+	}
+
+	if err := HTTPChecker(server.URL, 200, 0, nil, WithExpectedHeaderRegexp("X-Backend", regexp.MustCompile(`^secondary-\d+$`))).Check(); err == nil {
+		t.Errorf("header regexp was expected not to match")
+	}
+}
+
+func TestTCPChecker(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	addr := l.Addr().String()
+
+	if err := TCPChecker(addr, time.Second).Check(); err != nil {
+		t.Errorf("connection to %s was expected to succeed, error:%v", addr, err)
+	}
+
+	l.Close()
+	if err := TCPChecker(addr, time.Second).Check(); err == nil || !strings.Contains(err.Error(), addr) {
+		t.Errorf("connection to %s was expected to fail, error:%v", addr, err)
+	}
+}
+
+func TestReplicationLagChecker(t *testing.T) {
+	lag := func(l time.Duration, err error) func() (time.Duration, error) {
+		return func() (time.Duration, error) { return l, err }
+	}
+
+	if err := ReplicationLagChecker(lag(time.Second, nil), 5*time.Second).Check(); err != nil {
+		t.Errorf("lag below the maximum was expected to pass, error:%v", err)
+	}
+
+	if err := ReplicationLagChecker(lag(10*time.Second, nil), 5*time.Second).Check(); err == nil {
+		t.Errorf("lag above the maximum was expected to fail")
+	}
+
+	if err := ReplicationLagChecker(lag(0, errors.New("replica unreachable")), 5*time.Second).Check(); err == nil {
+		t.Errorf("probe error was expected to fail")
+	}
+}
+
+func TestMembershipChecker(t *testing.T) {
+	peers := 3
+	checker := MembershipChecker(func() int { return peers }, 3)
+
+	if err := checker.Check(); err != nil {
+		t.Errorf("full membership was expected to pass, error:%v", err)
+	}
+
+	peers = 2
+	if err := checker.Check(); err == nil {
+		t.Errorf("reduced membership was expected to fail")
+	}
+}
+
+func TestPortFreeChecker(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	addr := l.Addr().String()
+
+	if err := PortFreeChecker(addr).Check(); err == nil {
+		t.Errorf("%s was expected as in use", addr)
+	}
+
+	l.Close()
+	if err := PortFreeChecker(addr).Check(); err != nil {
+		t.Errorf("%s was expected as free, error:%v", addr, err)
+	}
+}
+
+func TestLicenseChecker(t *testing.T) {
+	expiry := func(d time.Duration) func() time.Time {
+		return func() time.Time { return time.Now().Add(d) }
+	}
+
+	if err := LicenseChecker(expiry(30*24*time.Hour), 7*24*time.Hour).Check(); err != nil {
+		t.Errorf("valid license was expected to pass, error:%v", err)
+	}
+
+	err := LicenseChecker(expiry(24*time.Hour), 7*24*time.Hour).Check()
+	if health.SeverityOf(err) != health.SeverityWarning {
+		t.Errorf("license about to expire was expected to warn, error:%v", err)
+	}
+
+	err = LicenseChecker(expiry(-time.Hour), 7*24*time.Hour).Check()
+	if health.SeverityOf(err) != health.SeverityCritical {
+		t.Errorf("expired license was expected to fail, error:%v", err)
+	}
+}
+
+func TestTrafficChecker(t *testing.T) {
+	var clock RequestClock
+	checker := TrafficChecker(clock.Last, time.Minute)
+
+	if err := checker.Check(); err == nil {
+		t.Errorf("no traffic was expected to fail")
+	}
+
+	handler := clock.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if err := checker.Check(); err != nil {
+		t.Errorf("recent traffic was expected to pass, error:%v", err)
+	}
+
+	if err := TrafficChecker(func() time.Time { return time.Now().Add(-time.Hour) }, time.Minute).Check(); err == nil {
+		t.Errorf("stale traffic was expected to fail")
+	}
+}
+
+func TestSidecarChecker(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	addr := l.Addr().String()
+
+	if err := SidecarChecker(addr, time.Second).Check(); err != nil {
+		t.Errorf("listening sidecar was expected to pass, error:%v", err)
+	}
+
+	l.Close()
+	if err := SidecarChecker(addr, time.Second).Check(); err == nil {
+		t.Errorf("stopped sidecar was expected to fail")
+	}
+}
+
+func TestSecretChecker(t *testing.T) {
+	secret := func(s string, err error) func() (string, error) {
+		return func() (string, error) { return s, err }
+	}
+
+	if err := SecretChecker(secret("hunter2", nil)).Check(); err != nil {
+		t.Errorf("present secret was expected to pass, error:%v", err)
+	}
+
+	if err := SecretChecker(secret(" \n", nil)).Check(); err == nil {
+		t.Errorf("blank secret was expected to fail")
+	}
+
+	if err := SecretChecker(secret("", errors.New("no such file"))).Check(); err == nil {
+		t.Errorf("missing secret was expected to fail")
+	}
+}
+
+func TestHTTPCheckerRequestBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" || string(body) != `{"probe":true}` {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	checker := HTTPChecker(server.URL, 200, 0, nil,
+		WithMethod("POST"), WithBody([]byte(`{"probe":true}`)), WithContentType("application/json"))
+	for i := 0; i < 2; i++ {
+		if err := checker.Check(); err != nil {
+			t.Errorf("probe %d was expected to send the body, error:%v", i, err)
+		}
+	}
+
+	if err := HTTPChecker(server.URL, 200, 0, nil).Check(); err == nil {
+		t.Errorf("HEAD probe was expected to be rejected")
+	}
+}
+
+func TestReadinessMatrixChecker(t *testing.T) {
+	migrated, warm := false, false
+	checker := ReadinessMatrixChecker(map[string]func() bool{
+		"migrations": func() bool { return migrated },
+		"cache":      func() bool { return warm },
+		"config":     func() bool { return true },
+	})
+
+	if err := checker.Check(); err == nil || err.Error() != "waiting for 2 of 3 steps: cache, migrations" {
+		t.Errorf("unexpected error with steps remaining: %v", err)
+	}
+
+	migrated, warm = true, true
+	if err := checker.Check(); err != nil {
+		t.Errorf("completed steps were expected to pass, error:%v", err)
+	}
+}
+
+func TestDNSServerChecker(t *testing.T) {
+	// A resolver that never answers.
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer conn.Close()
+	addr := conn.LocalAddr().String()
+
+	err = DNSServerChecker(addr, "registry.example", 50*time.Millisecond).Check()
+	if err == nil {
+		t.Fatalf("lookup through %s was expected to fail", addr)
+	}
+	if !strings.Contains(err.Error(), addr) || !strings.Contains(err.Error(), "registry.example") {
+		t.Errorf("error was expected to name the resolver and host: %v", err)
+	}
+}
+
+func TestConsumerProgressChecker(t *testing.T) {
+	var processed uint64
+	checker := ConsumerProgressChecker(func() uint64 { return processed }, 10, 20*time.Millisecond)
+
+	if err := checker.Check(); err != nil {
+		t.Errorf("check was expected to pass without enough samples, error:%v", err)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if err := checker.Check(); err == nil || !strings.Contains(err.Error(), "processing rate 0.00/s") {
+		t.Errorf("stuck consumer was expected to fail with its rate, error:%v", err)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	processed = 1000
+	if err := checker.Check(); err != nil {
+		t.Errorf("consumer making progress was expected to pass, error:%v", err)
+	}
+}
+
+func TestHTTPCheckerClosesBody(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "some body")
+	}))
+	var mu sync.Mutex
+	conns := 0
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			conns++
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	checker := HTTPChecker(server.URL, http.StatusOK, time.Second, nil, WithMethod(http.MethodGet))
+	for i := 0; i < 3; i++ {
+		if err := checker.Check(); err != nil {
+			t.Fatalf("unexpected error:%v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if conns != 1 {
+		t.Errorf("the connection was expected to be reused, got %d connections", conns)
+	}
+}
+
+func TestDNSChecker(t *testing.T) {
+	if err := DNSChecker("localhost", time.Second).Check(); err != nil {
+		t.Errorf("localhost was expected to resolve, error:%v", err)
+	}
+
+	if err := DNSChecker("no-such-host.invalid", time.Second).Check(); err == nil {
+		t.Errorf("no-such-host.invalid was not expected to resolve")
+	}
+}
+
+func TestDiskSpaceChecker(t *testing.T) {
+	dir := t.TempDir()
+	if err := DiskSpaceChecker(dir, 1).Check(); err != nil {
+		t.Errorf("a byte was expected to be free, error:%v", err)
+	}
+
+	if err := DiskSpaceChecker(dir, 1<<63).Check(); err == nil || !strings.Contains(err.Error(), "bytes free") {
+		t.Errorf("8EiB were not expected to be free, error:%v", err)
+	}
+
+	if err := DiskSpaceChecker("NoSuchFileFromMoon", 1).Check(); err == nil || !strings.Contains(err.Error(), "error checking free space") {
+		t.Errorf("stat failure was expected to be reported, error:%v", err)
+	}
+}
+
+func TestMemoryChecker(t *testing.T) {
+	if err := MemoryChecker(1 << 62).Check(); err != nil {
+		t.Errorf("heap was expected to be below 4EiB, error:%v", err)
+	}
+
+	if err := MemoryChecker(1).Check(); err == nil || !strings.Contains(err.Error(), "heap allocation of") {
+		t.Errorf("heap was expected to exceed a byte, error:%v", err)
+	}
+}
+
+func TestCommandChecker(t *testing.T) {
+	if err := CommandChecker("sh", []string{"-c", "exit 0"}, time.Second).Check(); err != nil {
+		t.Errorf("successful command was expected to pass, error:%v", err)
+	}
+
+	err := CommandChecker("sh", []string{"-c", "echo replica lagging >&2; exit 2"}, time.Second).Check()
+	if err == nil || !strings.Contains(err.Error(), "exit status 2: replica lagging") {
+		t.Errorf("failing command was expected to report its stderr, error:%v", err)
+	}
+
+	start := time.Now()
+	err = CommandChecker("sleep", []string{"10"}, 50*time.Millisecond).Check()
+	if err == nil || !strings.Contains(err.Error(), "did not complete") {
+		t.Errorf("hung command was expected to time out, error:%v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("hung command was expected to be killed, took %v", elapsed)
+	}
+}
+
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) PingContext(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestDBChecker(t *testing.T) {
+	if err := DBChecker(pingerFunc(func(ctx context.Context) error { return nil })).Check(); err != nil {
+		t.Errorf("reachable database was expected to pass, error:%v", err)
+	}
+
+	err := DBChecker(pingerFunc(func(ctx context.Context) error { return errors.New("connection refused") })).Check()
+	if err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("unreachable database was expected to fail, error:%v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	checker := DBChecker(pingerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	if err := checker.(health.ContextChecker).CheckContext(ctx); !errors.Is(ctx.Err(), context.DeadlineExceeded) || err == nil {
+		t.Errorf("ping was expected to be bounded by the evaluation deadline, error:%v", err)
+	}
+}
+
+func TestRemoteHealthChecker(t *testing.T) {
+	body := `{"status": "healthy", "checks": {}}`
+	code := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/json" {
+			t.Errorf("JSON was expected to be requested, got Accept: %q", r.Header.Get("Accept"))
+		}
+		w.WriteHeader(code)
+		io.WriteString(w, body)
+	}))
+	defer ts.Close()
+	checker := RemoteHealthChecker(ts.URL, time.Second)
+
+	if err := checker.Check(); err != nil {
+		t.Errorf("healthy downstream was expected to pass, error:%v", err)
+	}
+
+	body = `{"status": "degraded", "checks": {}, "warnings": {"lag": "replica lagging"}}`
+	err := checker.Check()
+	if health.SeverityOf(err) != health.SeverityWarning || !strings.Contains(err.Error(), "lag: replica lagging") {
+		t.Errorf("degraded downstream was expected to warn, error:%v", err)
+	}
+
+	body, code = `{"status": "unhealthy", "checks": {"db": "down", "cache": "timeout"}}`, http.StatusServiceUnavailable
+	err = checker.Check()
+	if !errors.Is(err, ErrRemoteUnhealthy) || !strings.Contains(err.Error(), "cache: timeout; db: down") {
+		t.Errorf("unhealthy downstream was expected to report its failing checks, error:%v", err)
+	}
+
+	body = `{"status": "unhealthy", "checks": {}}`
+	err = checker.Check()
+	if !errors.Is(err, ErrRemoteUnhealthy) || strings.HasSuffix(err.Error(), ": ") {
+		t.Errorf("unhealthy downstream hiding its checks was expected to fail without details, error:%v", err)
+	}
+
+	body = `<html>Bad Gateway</html>`
+	err = checker.Check()
+	if err == nil || errors.Is(err, ErrRemoteUnhealthy) || !strings.Contains(err.Error(), "invalid health status") {
+		t.Errorf("invalid response was expected to be reported apart, error:%v", err)
+	}
+
+	ts.Close()
+	err = checker.Check()
+	if err == nil || errors.Is(err, ErrRemoteUnhealthy) || !strings.Contains(err.Error(), "unreachable") {
+		t.Errorf("unreachable downstream was expected to be reported apart, error:%v", err)
+	}
+
+	err = RemoteHealthChecker("http://[::1", time.Second).Check()
+	if err == nil || !strings.Contains(err.Error(), "error creating request: http://[::1: ") {
+		t.Errorf("invalid URL was expected to be reported with its cause, error:%v", err)
+	}
+}
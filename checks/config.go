@@ -0,0 +1,186 @@
+package checks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/docker/distribution/health"
+)
+
+// checkConfig is the declaration of a check in the configuration read by
+// LoadChecksFromJSON.
+type checkConfig struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Addr      string   `json:"addr"`      // tcp
+	URL       string   `json:"url"`       // http
+	Status    int      `json:"status"`    // http, 200 by default
+	Path      string   `json:"path"`      // file, file_exists
+	Host      string   `json:"host"`      // dns
+	Timeout   duration `json:"timeout"`   // tcp, http, dns, defaultConfiguredTimeout by default
+	Period    duration `json:"period"`    // runs the check periodically when set
+	Threshold int      `json:"threshold"` // consecutive failures reported, with period
+}
+
+// duration is a time.Duration read from a JSON string such as "5s".
+type duration time.Duration
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (d *duration) UnmarshalJSON(p []byte) error {
+	var s string
+	if err := json.Unmarshal(p, &s); err != nil {
+		return errors.New("duration must be a string such as \"5s\"")
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+// LoadChecksFromJSON registers with r the checks declared in data, so that
+// they can be configured without recompiling. data holds a JSON object listing
+// checks of the built-in types, with their parameters:
+//
+//	{"checks": [
+//		{"name": "db", "type": "tcp", "addr": "db:5432", "timeout": "2s", "period": "10s"},
+//		{"name": "api", "type": "http", "url": "http://api/health", "status": 200, "timeout": "2s"},
+//		{"name": "down", "type": "file", "path": "/etc/app/down"},
+//		{"name": "config", "type": "file_exists", "path": "/etc/app/config.yml"},
+//		{"name": "dns", "type": "dns", "host": "example.com", "timeout": "1s",
+//			"period": "30s", "threshold": 3}
+//	]}
+//
+// The checks of the network types, tcp, http and dns, time out after 5
+// seconds unless they set a timeout. Checks with a period run as periodic
+// checks, reporting failures only after threshold consecutive ones if it is
+// set. An error is returned, without
+// registering any check, if data is invalid, such as for unknown check types,
+// missing parameters or names already registered.
+func LoadChecksFromJSON(r *health.Registry, data []byte) error {
+	var config struct {
+		Checks []checkConfig `json:"checks"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		return errors.New("error parsing health check configuration: " + err.Error())
+	}
+
+	names := make(map[string]bool)
+	for _, name := range r.CheckedKeys() {
+		names[name] = true
+	}
+	checkers := make([]health.Checker, len(config.Checks))
+	for i, c := range config.Checks {
+		if c.Name == "" {
+			return fmt.Errorf("health check %d has no name", i)
+		}
+		if names[c.Name] {
+			return fmt.Errorf("health check %q is already registered", c.Name)
+		}
+		names[c.Name] = true
+
+		checker, err := newConfiguredChecker(c)
+		if err != nil {
+			return fmt.Errorf("health check %q: %v", c.Name, err)
+		}
+		checkers[i] = checker
+	}
+
+	// periodic checks are only started once every entry is valid
+	for i, c := range config.Checks {
+		if err := register(r, c.Name, periodic(checkers[i], c)); err != nil {
+			// a check was registered concurrently with the same name
+			for _, registered := range config.Checks[:i] {
+				r.Unregister(registered.Name)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// register registers checker with r under name, returning an error rather
+// than panicking if a check is already registered with name, in which case a
+// StoppableChecker is stopped.
+func register(r *health.Registry, name string, checker health.Checker) (err error) {
+	defer func() {
+		if recover() != nil {
+			if s, ok := checker.(health.StoppableChecker); ok {
+				s.Stop()
+			}
+			err = fmt.Errorf("health check %q is already registered", name)
+		}
+	}()
+	r.Register(name, checker)
+	return nil
+}
+
+// defaultConfiguredTimeout bounds the checks of the network types loaded by
+// LoadChecksFromJSON without a timeout. It is a variable for tests.
+var defaultConfiguredTimeout = 5 * time.Second
+
+// newConfiguredChecker returns the checker declared by c, without running it
+// periodically, see periodic.
+func newConfiguredChecker(c checkConfig) (health.Checker, error) {
+	var checker health.Checker
+	timeout := time.Duration(c.Timeout)
+	if timeout <= 0 {
+		timeout = defaultConfiguredTimeout
+	}
+	switch c.Type {
+	case "tcp":
+		if c.Addr == "" {
+			return nil, errors.New("tcp check requires an addr")
+		}
+		checker = TCPChecker(c.Addr, timeout)
+	case "http":
+		if c.URL == "" {
+			return nil, errors.New("http check requires a url")
+		}
+		status := c.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		checker = HTTPChecker(c.URL, status, timeout, nil)
+	case "file":
+		if c.Path == "" {
+			return nil, errors.New("file check requires a path")
+		}
+		checker = FileChecker(c.Path)
+	case "file_exists":
+		if c.Path == "" {
+			return nil, errors.New("file_exists check requires a path")
+		}
+		checker = FileExistsChecker(c.Path)
+	case "dns":
+		if c.Host == "" {
+			return nil, errors.New("dns check requires a host")
+		}
+		checker = DNSChecker(c.Host, timeout)
+	default:
+		return nil, fmt.Errorf("unknown check type %q", c.Type)
+	}
+
+	if c.Period <= 0 && c.Threshold != 0 {
+		return nil, errors.New("threshold requires a period")
+	}
+	return checker, nil
+}
+
+// periodic returns checker running periodically if c declares a period.
+func periodic(checker health.Checker, c checkConfig) health.Checker {
+	if c.Period <= 0 {
+		return checker
+	}
+	if c.Threshold > 0 {
+		return health.PeriodicThresholdChecker(checker, time.Duration(c.Period), c.Threshold)
+	}
+	return health.PeriodicChecker(checker, time.Duration(c.Period))
+}
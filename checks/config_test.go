@@ -0,0 +1,138 @@
+package checks
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/health"
+)
+
+func TestLoadChecksFromJSON(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+
+	registry := health.NewRegistry()
+	err = LoadChecksFromJSON(registry, []byte(`{"checks": [
+		{"name": "listener", "type": "tcp", "addr": "`+l.Addr().String()+`", "timeout": "1s"},
+		{"name": "down", "type": "file", "path": "NoSuchFileFromMoon"},
+		{"name": "config", "type": "file_exists", "path": "NoSuchFileFromMoon", "period": "1h", "threshold": 2}
+	]}`))
+	if err != nil {
+		t.Fatalf("unexpected error:%v", err)
+	}
+	if keys := registry.CheckedKeys(); strings.Join(keys, ",") != "config,down,listener" {
+		t.Errorf("unexpected checks: %v", keys)
+	}
+	for name, r := range registry.CheckStatus() {
+		if r.Err != nil {
+			t.Errorf("%s was expected to pass, error:%v", name, r.Err)
+		}
+	}
+
+	for _, tc := range []struct {
+		config, err string
+	}{
+		{`{"checks": [{"name": "x", "type": "smtp"}]}`, `unknown check type "smtp"`},
+		{`{"checks": [{"name": "x", "type": "tcp"}]}`, "requires an addr"},
+		{`{"checks": [{"name": "x", "type": "tcp", "addr": "a:1", "timeout": 5}]}`, "duration must be a string"},
+		{`{"checks": [{"name": "x", "type": "tcp", "adr": "a:1"}]}`, "unknown field"},
+		{`{"checks": [{"name": "down", "type": "file", "path": "x"}]}`, "already registered"},
+		{`{"checks": [{"name": "x", "type": "file", "path": "x", "threshold": 2}]}`, "threshold requires a period"},
+	} {
+		err := LoadChecksFromJSON(registry, []byte(tc.config))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected an error containing %q, error:%v", tc.config, tc.err, err)
+		}
+	}
+	if n := registry.Count(); n != 3 {
+		t.Errorf("invalid configurations were not expected to register checks, got %d checks", n)
+	}
+}
+
+// TestLoadChecksFromJSONInvalidPeriodic ensures that no periodic check is
+// started when a later entry is invalid.
+func TestLoadChecksFromJSONInvalidPeriodic(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer l.Close()
+
+	err = LoadChecksFromJSON(health.NewRegistry(), []byte(`{"checks": [
+		{"name": "listener", "type": "tcp", "addr": "`+l.Addr().String()+`", "period": "1ms"},
+		{"name": "x", "type": "smtp"}
+	]}`))
+	if err == nil {
+		t.Fatalf("invalid configuration was expected to fail")
+	}
+
+	l.(*net.TCPListener).SetDeadline(time.Now().Add(50 * time.Millisecond))
+	if conn, err := l.Accept(); err == nil {
+		conn.Close()
+		t.Errorf("periodic check of an invalid configuration was not expected to run")
+	}
+}
+
+// TestLoadChecksFromJSONDefaultTimeout ensures that network checks loaded
+// without a timeout cannot hang.
+func TestLoadChecksFromJSONDefaultTimeout(t *testing.T) {
+	defer func(d time.Duration) { defaultConfiguredTimeout = d }(defaultConfiguredTimeout)
+	defaultConfiguredTimeout = 50 * time.Millisecond
+
+	hang := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hang
+	}))
+	defer ts.Close()
+	defer close(hang)
+
+	registry := health.NewRegistry()
+	if err := LoadChecksFromJSON(registry, []byte(`{"checks": [{"name": "api", "type": "http", "url": "`+ts.URL+`"}]}`)); err != nil {
+		t.Fatalf("unexpected error:%v", err)
+	}
+	start := time.Now()
+	if err := registry.CheckStatus()["api"].Err; err == nil {
+		t.Errorf("hanging check was expected to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("check was expected to time out by default, took %v", elapsed)
+	}
+}
+
+// TestRegisterDuplicate ensures that a check registered concurrently under
+// the same name is reported as an error, its goroutine being stopped.
+func TestRegisterDuplicate(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.Register("db", health.CheckFunc(func() error { return nil }))
+
+	runs := 0
+	var mu sync.Mutex
+	checker := health.PeriodicChecker(health.CheckFunc(func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		runs++
+		return nil
+	}), time.Millisecond)
+	if err := register(registry, "db", checker); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("duplicate check was expected to be reported, error:%v", err)
+	}
+
+	mu.Lock()
+	before := runs
+	mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	after := runs
+	mu.Unlock()
+	if after > before+1 {
+		t.Errorf("duplicate periodic check was expected to be stopped: %d runs", after-before)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build darwin || freebsd
+
+package checks
+
+import "syscall"
+
+// blockSize returns the unit in which stat counts blocks, the fundamental
+// block size in Bsize on BSD systems.
+func blockSize(stat *syscall.Statfs_t) uint64 {
+	return uint64(stat.Bsize)
+}
@@ -0,0 +1,9 @@
+package checks
+
+import "syscall"
+
+// blockSize returns the unit in which stat counts blocks, the fragment size on
+// Linux, which can differ from the preferred I/O size in Bsize.
+func blockSize(stat *syscall.Statfs_t) uint64 {
+	return uint64(stat.Frsize)
+}
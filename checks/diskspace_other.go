@@ -0,0 +1,13 @@
+//go:build !darwin && !freebsd && !linux
+
+package checks
+
+import (
+	"errors"
+	"runtime"
+)
+
+// freeSpace reports that free space cannot be determined on this platform.
+func freeSpace(path string) (uint64, error) {
+	return 0, errors.New("free space is not supported on " + runtime.GOOS)
+}
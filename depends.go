@@ -0,0 +1,71 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSkipped is reported, with SeverityWarning, for checks which were not run
+// because a check they depend on failed critically or was itself skipped, see
+// WithDependsOn.
+var ErrSkipped = errors.New("health check skipped")
+
+// WithDependsOn declares that the check depends on the checks registered with
+// names: when any of them fails critically in an evaluation, or is skipped,
+// the check is not run and reports ErrSkipped instead of failing noisily. Dependencies are only
+// honored within an evaluation including them, and need not be registered
+// first. Registering a check closing a dependency cycle panics.
+func WithDependsOn(names ...string) CheckOption {
+	return func(rc *registeredCheck) {
+		rc.dependsOn = append(rc.dependsOn, names...)
+	}
+}
+
+// dependencyCycle returns the cycle registering rc with name would close, as
+// the names of the checks along it starting and ending with name, or nil if
+// there is none. The caller must hold registry.mu.
+func (registry *Registry) dependencyCycle(name string, rc *registeredCheck) []string {
+	visited := make(map[string]bool)
+	var visit func(path []string, dependsOn []string) []string
+	visit = func(path []string, dependsOn []string) []string {
+		for _, dep := range dependsOn {
+			if dep == name {
+				return append(path, dep)
+			}
+			if visited[dep] {
+				continue
+			}
+			visited[dep] = true
+			if d, ok := registry.registeredChecks[dep]; ok {
+				if cycle := visit(append(path, dep), d.dependsOn); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		return nil
+	}
+	return visit([]string{name}, rc.dependsOn)
+}
+
+// skippedResult returns the result of the check skipped because the named
+// check it depends on failed, or was skipped if depSkipped is set.
+func skippedResult(rc *registeredCheck, dep string, depSkipped bool) result {
+	reason := "failing"
+	if depSkipped {
+		reason = "skipped"
+	}
+	r := result{
+		err: &StatusError{
+			Severity: SeverityWarning,
+			Err:      fmt.Errorf("%w: depends on %s check %q", ErrSkipped, reason, dep),
+		},
+		informational: rc.informational,
+		owner:         rc.owner,
+		weight:        rc.weight,
+		skipped:       true,
+		timestamp:     time.Now(),
+	}
+	r.invocations, r.lastTrigger = rc.invocationStats()
+	return r
+}
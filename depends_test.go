@@ -0,0 +1,87 @@
+package health
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestDependsOn ensures that checks depending on a failing check are skipped
+// rather than run.
+func TestDependsOn(t *testing.T) {
+	// a single goroutine must not deadlock on a dependent check
+	pool := NewPool(1)
+	defer pool.Close()
+	registry := NewRegistry()
+	registry.SetPool(pool)
+
+	var dbErr error
+	ran := false
+	registry.RegisterFunc("cache-warmer", func() error {
+		ran = true
+		return errors.New("cache is cold")
+	}, WithDependsOn("db"))
+	registry.RegisterFunc("db", func() error { return dbErr })
+
+	dbErr = errors.New("down")
+	report := registry.Report()
+	if ran {
+		t.Errorf("check depending on a failing check was not expected to run")
+	}
+	cr := report.Checks["cache-warmer"]
+	if !cr.Skipped || cr.Status != "warning" || !strings.Contains(cr.Error, "skipped") {
+		t.Errorf("unexpected report of the skipped check: %+v", cr)
+	}
+	if cr := report.Checks["db"]; cr.Skipped || cr.Status != "critical" {
+		t.Errorf("unexpected report of the failing check: %+v", cr)
+	}
+
+	dbErr = nil
+	report = registry.Report()
+	if !ran {
+		t.Errorf("check depending on a passing check was expected to run")
+	}
+	if cr := report.Checks["cache-warmer"]; cr.Skipped || cr.Status != "critical" {
+		t.Errorf("unexpected report of the dependent check: %+v", cr)
+	}
+}
+
+// TestDependsOnCycle ensures that registering a check closing a dependency
+// cycle panics.
+func TestDependsOnCycle(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("a", func() error { return nil }, WithDependsOn("b"))
+	registry.RegisterFunc("b", func() error { return nil }, WithDependsOn("c"))
+
+	defer func() {
+		r := recover()
+		if r == nil || !strings.Contains(r.(string), "c -> a -> b -> c") {
+			t.Errorf("a dependency cycle was expected to panic, got %v", r)
+		}
+	}()
+	registry.RegisterFunc("c", func() error { return nil }, WithDependsOn("a"))
+}
+
+// TestDependsOnTransitive ensures that a check depending on a skipped check is
+// skipped too.
+func TestDependsOnTransitive(t *testing.T) {
+	registry := NewRegistry()
+	ran := false
+	registry.RegisterFunc("a", func() error {
+		ran = true
+		return nil
+	}, WithDependsOn("b"))
+	registry.RegisterFunc("b", func() error { return errors.New("b ran") }, WithDependsOn("c"))
+	registry.RegisterFunc("c", func() error { return errors.New("down") })
+
+	report := registry.Report()
+	if ran {
+		t.Errorf("check depending on a skipped check was not expected to run")
+	}
+	if cr := report.Checks["b"]; !cr.Skipped || !strings.Contains(cr.Error, `failing check "c"`) {
+		t.Errorf("unexpected report of the check depending on the failing check: %+v", cr)
+	}
+	if cr := report.Checks["a"]; !cr.Skipped || !strings.Contains(cr.Error, `skipped check "b"`) {
+		t.Errorf("unexpected report of the check depending on the skipped check: %+v", cr)
+	}
+}
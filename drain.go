@@ -0,0 +1,117 @@
+package health
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ErrDraining is the synthetic failure reported by readiness probes while the
+// registry is draining, see BeginDrain.
+var ErrDraining = errors.New("draining")
+
+// drainingCheck is the name under which readiness probes report ErrDraining,
+// reserved by the registries.
+const drainingCheck = "draining"
+
+// BeginDrain puts the registry in drain mode, typically at the beginning of a
+// deploy: readiness probes, such as ReadinessHandler and StatusHandler, as
+// well as the Report and the gRPC health server, fail with ErrDraining,
+// reported under the "draining" name, which cannot be registered, so that load
+// balancers stop sending new traffic. Liveness probes keep reporting the
+// actual checks so that the application is not restarted, and Handler keeps
+// serving requests, so that those still arriving complete. Entering and leaving drain mode are
+// reported as state changes of "draining", see OnStateChange. Drain mode lasts
+// until EndDrain is called.
+func (registry *Registry) BeginDrain() {
+	registry.setDraining(true)
+}
+
+// EndDrain takes the registry out of the drain mode entered with BeginDrain.
+func (registry *Registry) EndDrain() {
+	registry.setDraining(false)
+}
+
+// Draining reports whether the registry is in drain mode, see BeginDrain.
+func (registry *Registry) Draining() bool {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return registry.draining
+}
+
+// setDraining enters or leaves drain mode, recording the transition if any.
+func (registry *Registry) setDraining(draining bool) {
+	registry.mu.Lock()
+	changed := registry.draining != draining
+	registry.draining = draining
+	registry.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	change := StateChange{Name: drainingCheck, Healthy: !draining, Time: time.Now()}
+	if draining {
+		change.Err = ErrDraining
+	}
+	registry.recordEvent(change)
+}
+
+// drain adds the failure of draining to the results of a readiness probe if
+// the registry is draining.
+func (registry *Registry) drain(results map[string]result) {
+	if registry.Draining() {
+		results[drainingCheck] = result{err: ErrDraining, weight: 1, draining: true, timestamp: time.Now()}
+	}
+}
+
+// DrainOnSignal makes the registry enter drain mode, see BeginDrain, when the
+// process receives one of sigs, SIGTERM if none is given. As the signals are
+// caught, the application remains responsible for shutting down, for
+// instance after a grace period letting load balancers notice the drain.
+// Calling the returned function stops relaying the signals.
+func (registry *Registry) DrainOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGTERM}
+	}
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, sigs...)
+	go func() {
+		for {
+			select {
+			case <-c:
+				registry.BeginDrain()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
+
+// BeginDrain puts the default registry in drain mode.
+func BeginDrain() {
+	DefaultRegistry.BeginDrain()
+}
+
+// EndDrain takes the default registry out of drain mode.
+func EndDrain() {
+	DefaultRegistry.EndDrain()
+}
+
+// Draining reports whether the default registry is in drain mode.
+func Draining() bool {
+	return DefaultRegistry.Draining()
+}
+
+// DrainOnSignal makes the default registry enter drain mode when the process
+// receives one of sigs, SIGTERM if none is given.
+func DrainOnSignal(sigs ...os.Signal) (stop func()) {
+	return DefaultRegistry.DrainOnSignal(sigs...)
+}
@@ -0,0 +1,112 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDrain(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("ok", CheckFunc(func() error { return nil }))
+	readiness := NewStatusHandler(registry)
+	liveness := NewStatusHandler(registry, WithLiveness())
+
+	serve := func(h http.Handler) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		return recorder
+	}
+
+	registry.BeginDrain()
+	if !registry.Draining() {
+		t.Errorf("registry was expected to be draining")
+	}
+	recorder := serve(readiness)
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Did not get a 503 while draining, got %d", recorder.Code)
+	}
+	if body := recorder.Body.String(); !strings.Contains(body, `"draining":"draining"`) {
+		t.Errorf("drain state was expected in the response, got %s", body)
+	}
+	if code := serve(liveness).Code; code != http.StatusOK {
+		t.Errorf("liveness was expected to pass while draining, got %d", code)
+	}
+
+	report := registry.Report()
+	if report.Status != StatusUnhealthy || report.Checks["draining"].Error != "draining" {
+		t.Errorf("drain state was expected in the report: %+v", report)
+	}
+	if events := registry.RecentEvents(1); len(events) != 1 || events[0].Name != "draining" || events[0].Healthy {
+		t.Errorf("entering drain mode was expected to be recorded: %+v", events)
+	}
+
+	registry.EndDrain()
+	if code := serve(readiness).Code; code != http.StatusOK {
+		t.Errorf("Did not get a 200 after draining, got %d", code)
+	}
+	if status := registry.Report().Status; status != StatusHealthy {
+		t.Errorf("report was expected to be healthy after draining, got %s", status)
+	}
+}
+
+// TestDrainHandler ensures that Handler keeps serving requests while
+// draining, although the status handler reports the application unhealthy.
+func TestDrainHandler(t *testing.T) {
+	// clear out existing checks.
+	DefaultRegistry = NewRegistry()
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	BeginDrain()
+	defer EndDrain()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Did not get a 200 from the application while draining, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	StatusHandler(recorder, httptest.NewRequest("GET", "/debug/health", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Did not get a 503 from /debug/health while draining, got %d", recorder.Code)
+	}
+}
+
+// TestDrainingNameReserved ensures that no check can be registered under the
+// name reporting drain mode.
+func TestDrainingNameReserved(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("registering a check named draining was expected to panic")
+		}
+	}()
+	NewRegistry().Register("draining", CheckFunc(func() error { return nil }))
+}
+
+func TestDrainOnSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals cannot be sent to the process on windows")
+	}
+	registry := NewRegistry()
+	stop := registry.DrainOnSignal(syscall.SIGHUP)
+	defer stop()
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("unexpected error:%v", err)
+	}
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Fatalf("unexpected error:%v", err)
+	}
+	for i := 0; i < 100 && !registry.Draining(); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if !registry.Draining() {
+		t.Errorf("registry was expected to drain on signal")
+	}
+}
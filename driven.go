@@ -0,0 +1,48 @@
+package health
+
+import "sync"
+
+// DrivenChecker returns a checker behaving like PeriodicChecker, except that
+// it has no goroutine of its own: check runs whenever Tick is called on the
+// registry it is registered with. This lets the caller own the cadence of the
+// checks, e.g. to align them on a clock shared across a fleet. The checker
+// reports healthy until its first tick.
+func DrivenChecker(check Checker) Checker {
+	return &periodicChecker{updater: NewStatusUpdater(), check: check, driven: true}
+}
+
+// DrivenThresholdChecker returns a checker behaving like
+// PeriodicThresholdChecker, except that check runs whenever Tick is called on
+// the registry it is registered with.
+func DrivenThresholdChecker(check Checker, threshold int) Checker {
+	return &periodicChecker{updater: NewThresholdStatusUpdater(threshold), check: check, driven: true}
+}
+
+// Tick runs every check of the registry created by DrivenChecker or
+// DrivenThresholdChecker once, concurrently, and returns once they all
+// completed. Paused checks are skipped.
+func (registry *Registry) Tick() {
+	registry.mu.RLock()
+	var driven []*periodicChecker
+	for _, rc := range registry.registeredChecks {
+		if pc, ok := rc.checker.(*periodicChecker); ok && pc.driven {
+			driven = append(driven, pc)
+		}
+	}
+	registry.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, pc := range driven {
+		wg.Add(1)
+		go func(pc *periodicChecker) {
+			defer wg.Done()
+			pc.tick()
+		}(pc)
+	}
+	wg.Wait()
+}
+
+// Tick runs every driven check of the default registry once.
+func Tick() {
+	DefaultRegistry.Tick()
+}
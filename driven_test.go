@@ -0,0 +1,35 @@
+package health
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestTick ensures that driven checks only run when the registry ticks.
+func TestTick(t *testing.T) {
+	registry := NewRegistry()
+
+	calls := 0
+	registry.Register("driven", DrivenChecker(CheckFunc(func() error {
+		calls++
+		return errors.New("down")
+	})))
+	registry.RegisterFunc("direct", func() error {
+		return nil
+	})
+
+	if status := registry.failingChecks(); len(status) != 0 || calls != 0 {
+		t.Fatalf("driven check was not expected to run before a tick: %v (%d calls)", status, calls)
+	}
+
+	registry.Tick()
+	if status := registry.failingChecks(); status["driven"] != "down" || calls != 1 {
+		t.Errorf("driven check was expected to run once on tick: %v (%d calls)", status, calls)
+	}
+
+	registry.PauseAll()
+	registry.Tick()
+	if calls != 1 {
+		t.Errorf("paused driven check was not expected to run on tick: %d calls", calls)
+	}
+}
@@ -0,0 +1,169 @@
+package health
+
+import "time"
+
+// maxEvents is the number of state changes retained by a registry.
+const maxEvents = 100
+
+// Default settings of the flap detection of a registry.
+const (
+	defaultFlapWindow    = 10 * time.Minute
+	defaultFlapThreshold = 4
+)
+
+// StateChange describes a check becoming healthy or unhealthy.
+type StateChange struct {
+	// Name is the name the check was registered with.
+	Name string
+
+	// Owner is the owner the check was registered with, see WithOwner.
+	Owner string
+
+	// Healthy is the state of the check after the transition.
+	Healthy bool
+
+	// Err is the error reported by the check after the transition, nil if
+	// it became healthy.
+	Err error
+
+	// Time is when the transition was observed.
+	Time time.Time
+}
+
+// transitionNotifier is implemented by checkers that detect their own
+// transitions between healthy and unhealthy, such as the updaters backing
+// periodic checks. The first result of a checker establishes its state and is
+// not a transition.
+type transitionNotifier interface {
+	// notifyTransitions registers listener to be called with the new status
+	// every time the checker becomes healthy or unhealthy.
+	notifyTransitions(listener func(status error))
+}
+
+// notify calls each listener with status.
+func notify(listeners []func(status error), status error) {
+	for _, listener := range listeners {
+		listener(status)
+	}
+}
+
+// recordEvent appends change to the recent events of the registry, dropping
+// the oldest event once maxEvents are retained, logs it if the registry has a
+// logger and reports it to the OnStateChange callbacks and subscribers.
+func (registry *Registry) recordEvent(change StateChange) {
+	registry.eventsMu.Lock()
+	registry.events = append(registry.events, change)
+	if len(registry.events) > maxEvents {
+		registry.events = registry.events[len(registry.events)-maxEvents:]
+	}
+	since := registry.stateSince[change.Name]
+	registry.stateSince[change.Name] = change.Time
+	registry.transitions[change.Name]++
+	logger := registry.logger
+	callbacks := registry.onStateChange
+	subscriptions := make([]*subscription, 0, len(registry.subscriptions))
+	for s := range registry.subscriptions {
+		subscriptions = append(subscriptions, s)
+	}
+	registry.eventsMu.Unlock()
+
+	if logger != nil {
+		logTransition(logger, change, change.Time.Sub(since))
+	}
+	for _, callback := range callbacks {
+		callback(change.Name, change.Healthy, change.Err)
+	}
+	for _, s := range subscriptions {
+		s.send(CheckResult{Name: change.Name, Err: change.Err, Timestamp: change.Time})
+	}
+}
+
+// OnStateChange registers callback to be called every time a check of the
+// registry becomes healthy or unhealthy, with the error of the check when it
+// becomes unhealthy. Only the transitions returned by RecentEvents are
+// reported, once each: updates that do not change the state of a check are
+// not. Callbacks run synchronously in the goroutine updating the check, in
+// the order they were registered, and should therefore return quickly.
+func (registry *Registry) OnStateChange(callback func(name string, healthy bool, err error)) {
+	registry.eventsMu.Lock()
+	defer registry.eventsMu.Unlock()
+	registry.onStateChange = append(registry.onStateChange, callback)
+}
+
+// OnStateChange registers callback to be called every time a check of the
+// default registry becomes healthy or unhealthy.
+func OnStateChange(callback func(name string, healthy bool, err error)) {
+	DefaultRegistry.OnStateChange(callback)
+}
+
+// RecentEvents returns up to the last n state changes of the checks in the
+// registry, newest first. The first result of a check is not a transition.
+// Only the transitions detected by checks that track their own state, such as
+// those created by PeriodicChecker, PeriodicThresholdChecker and
+// NewStatusUpdater, are recorded, and only the most recent 100 are retained.
+func (registry *Registry) RecentEvents(n int) []StateChange {
+	registry.eventsMu.Lock()
+	defer registry.eventsMu.Unlock()
+
+	if n > len(registry.events) {
+		n = len(registry.events)
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	events := make([]StateChange, 0, n)
+	for i := len(registry.events) - 1; len(events) < n; i-- {
+		events = append(events, registry.events[i])
+	}
+
+	return events
+}
+
+// SetFlapDetection configures how the registry detects flapping checks: a
+// check that transitioned more than threshold times within the last window is
+// flagged as flapping in the Report, even if it is currently healthy. By
+// default, checks are flapping after more than 4 transitions in 10 minutes.
+// Transitions are taken from those returned by RecentEvents.
+func (registry *Registry) SetFlapDetection(window time.Duration, threshold int) {
+	registry.eventsMu.Lock()
+	defer registry.eventsMu.Unlock()
+
+	registry.flapWindow = window
+	registry.flapThreshold = threshold
+}
+
+// recentTransitions returns the number of transitions of each check within
+// the flap detection window, along with the flap threshold.
+func (registry *Registry) recentTransitions() (map[string]int, int) {
+	registry.eventsMu.Lock()
+	defer registry.eventsMu.Unlock()
+
+	since := time.Now().Add(-registry.flapWindow)
+	counts := make(map[string]int)
+	for i := len(registry.events) - 1; i >= 0 && registry.events[i].Time.After(since); i-- {
+		counts[registry.events[i].Name]++
+	}
+
+	return counts, registry.flapThreshold
+}
+
+// TransitionCounts returns the number of transitions of each check of the
+// registry since it was registered, keyed by check name. Unlike RecentEvents,
+// counts are not capped. Checks that never transitioned are left out.
+func (registry *Registry) TransitionCounts() map[string]uint64 {
+	registry.eventsMu.Lock()
+	defer registry.eventsMu.Unlock()
+
+	counts := make(map[string]uint64, len(registry.transitions))
+	for name, n := range registry.transitions {
+		counts[name] = n
+	}
+	return counts
+}
+
+// RecentEvents returns up to the last n state changes of the checks in the
+// default registry, newest first.
+func RecentEvents(n int) []StateChange {
+	return DefaultRegistry.RecentEvents(n)
+}
@@ -0,0 +1,180 @@
+package health
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRecentEvents ensures that transitions of registered updaters are
+// recorded newest first, and that updates without a transition are not.
+func TestRecentEvents(t *testing.T) {
+	registry := NewRegistry()
+
+	db := NewStatusUpdater()
+	registry.Register("db", db)
+	cache := NewThresholdStatusUpdater(2)
+	registry.Register("cache", cache)
+
+	db.Update(nil)
+	db.Update(errors.New("connection refused"))
+	db.Update(errors.New("connection refused"))
+	cache.Update(errors.New("timeout")) // below threshold
+	cache.Update(errors.New("timeout"))
+	db.Update(nil)
+
+	events := registry.RecentEvents(10)
+	if len(events) != 3 {
+		t.Fatalf("unexpected number of events: %d != 3", len(events))
+	}
+
+	expected := []struct {
+		name    string
+		healthy bool
+	}{
+		{"db", true},
+		{"cache", false},
+		{"db", false},
+	}
+	for i, e := range expected {
+		if events[i].Name != e.name || events[i].Healthy != e.healthy {
+			t.Errorf("unexpected event %d: %+v", i, events[i])
+		}
+	}
+
+	if events := registry.RecentEvents(1); len(events) != 1 || events[0].Name != "db" {
+		t.Errorf("unexpected most recent event: %+v", events)
+	}
+}
+
+// TestNoEventAfterUnregister ensures that the updates of unregistered or
+// replaced checkers are no longer recorded, and that registering an updater
+// again records its transitions once.
+func TestNoEventAfterUnregister(t *testing.T) {
+	registry := NewRegistry()
+	var changes int
+	registry.OnStateChange(func(name string, healthy bool, err error) {
+		changes++
+	})
+
+	db := NewStatusUpdater()
+	registry.Register("db", db)
+	db.Update(nil)
+	registry.Unregister("db")
+	db.Update(errors.New("connection refused"))
+
+	cache := NewStatusUpdater()
+	registry.Register("cache", cache)
+	cache.Update(nil)
+	registry.ReplaceRegister("cache", NewStatusUpdater())
+	cache.Update(errors.New("timeout"))
+
+	queue := NewStatusUpdater()
+	registry.Register("queue", queue)
+	queue.Update(nil)
+	registry.Clear()
+	queue.Update(errors.New("full"))
+
+	if events := registry.RecentEvents(10); len(events) != 0 || changes != 0 {
+		t.Errorf("detached checkers were not expected to record events: %+v", events)
+	}
+
+	registry.Register("db", db)
+	db.Update(nil)
+	if events := registry.RecentEvents(10); len(events) != 1 || changes != 1 {
+		t.Errorf("registered again checker was expected to record one event: %+v", events)
+	}
+}
+
+// TestNoEventOnFirstResult ensures that the first result of a check, which
+// establishes its state, is not recorded as a transition.
+func TestNoEventOnFirstResult(t *testing.T) {
+	registry := NewRegistry()
+
+	healthy := NewStatusUpdater()
+	registry.Register("healthy", healthy)
+	unhealthy := NewStatusUpdater()
+	registry.Register("unhealthy", unhealthy)
+	threshold := NewThresholdStatusUpdater(0)
+	registry.Register("threshold", threshold)
+
+	healthy.Update(nil)
+	unhealthy.Update(errors.New("down"))
+	threshold.Update(errors.New("down"))
+
+	if events := registry.RecentEvents(10); len(events) != 0 {
+		t.Errorf("no event was expected for first results: %+v", events)
+	}
+}
+
+// TestFlapping ensures that checks transitioning often are flagged as
+// flapping in the report.
+func TestFlapping(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetFlapDetection(time.Minute, 2)
+
+	stable := NewStatusUpdater()
+	registry.Register("stable", stable)
+	flapping := NewStatusUpdater()
+	registry.Register("flapping", flapping)
+
+	stable.Update(nil)
+	stable.Update(errors.New("down"))
+	stable.Update(nil)
+	flapping.Update(nil)
+	for i := 0; i < 3; i++ {
+		flapping.Update(errors.New("down"))
+		flapping.Update(nil)
+	}
+
+	report := registry.Report()
+	if cr := report.Checks["stable"]; cr.Flapping || cr.RecentTransitions != 2 {
+		t.Errorf("unexpected flap detection of stable check: %+v", cr)
+	}
+	if cr := report.Checks["flapping"]; !cr.Flapping || cr.RecentTransitions != 6 {
+		t.Errorf("unexpected flap detection of flapping check: %+v", cr)
+	}
+}
+
+// TestTransitionCounts ensures that every transition of a check is counted.
+func TestTransitionCounts(t *testing.T) {
+	registry := NewRegistry()
+	updater := NewStatusUpdater()
+	registry.Register("db", updater)
+	updater.Update(nil)
+	for i := 0; i < maxEvents+1; i++ {
+		updater.Update(errors.New("down"))
+		updater.Update(nil)
+	}
+
+	if counts := registry.TransitionCounts(); counts["db"] != 2*(maxEvents+1) {
+		t.Errorf("unexpected transition counts: %v", counts)
+	}
+}
+
+// TestOnStateChange ensures that callbacks are called once per transition.
+func TestOnStateChange(t *testing.T) {
+	registry := NewRegistry()
+
+	var changes []string
+	registry.OnStateChange(func(name string, healthy bool, err error) {
+		if healthy {
+			changes = append(changes, name+" healthy")
+		} else {
+			changes = append(changes, name+" unhealthy: "+err.Error())
+		}
+	})
+
+	updater := NewThresholdStatusUpdater(2)
+	registry.Register("db", updater)
+	updater.Update(nil)
+	updater.Update(errors.New("down"))
+	updater.Update(errors.New("down"))
+	updater.Update(errors.New("still down"))
+	updater.Update(nil)
+	updater.Update(nil)
+
+	if len(changes) != 2 || changes[0] != "db unhealthy: down" || changes[1] != "db healthy" {
+		t.Errorf("unexpected state changes: %q", changes)
+	}
+}
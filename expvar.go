@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"expvar"
+)
+
+// PublishExpvar publishes the health of registry under name with the expvar
+// package, served as JSON by its /debug/vars handler like the responses of
+// StatusHandler to requests accepting JSON. To keep /debug/vars cheap, the
+// checks are not run for it: checks serving a cached result, such as periodic
+// checks, report that result, and the others report the result of their last
+// evaluation by a handler or a call to the registry, including being skipped.
+// Checks never evaluated yet are listed under "not_evaluated" without
+// affecting the health. Like expvar.Publish, it panics if name is already
+// published.
+func PublishExpvar(name string, registry *Registry) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		results := registry.lastResults()
+		return newStatusBody(registry.failures(results), results, registry.unhealthy(results))
+	}))
+}
+
+// lastResults returns the results of the checks of the registry without
+// running them: checks serving a cached result are evaluated, and the others
+// report the result of their last evaluation, or a result marked unevaluated
+// if they have never been evaluated.
+func (registry *Registry) lastResults() map[string]result {
+	results := registry.evaluateIf(context.Background(), func(rc *registeredCheck) bool {
+		_, ok := rc.checker.(cachedResult)
+		return ok
+	})
+
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	for name, rc := range registry.registeredChecks {
+		if _, ok := results[name]; ok {
+			continue
+		}
+		if r, ok := rc.lastResult(); ok {
+			results[name] = r
+		} else {
+			results[name] = result{owner: rc.owner, unevaluated: true}
+		}
+	}
+	return results
+}
+
+// setLastResult records r as the result of the last evaluation of the check.
+func (rc *registeredCheck) setLastResult(r result) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.last = r
+	rc.evaluated = true
+}
+
+// lastResult returns the result of the last evaluation of the check and
+// whether it was ever evaluated.
+func (rc *registeredCheck) lastResult() (result, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.last, rc.evaluated
+}
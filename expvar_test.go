@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestPublishExpvar ensures that the published health reports the last
+// results of the checks without running them.
+func TestPublishExpvar(t *testing.T) {
+	registry := NewRegistry()
+	var runs atomic.Int64
+	registry.Register("down", CheckFunc(func() error {
+		runs.Add(1)
+		return errors.New("down")
+	}))
+	registry.Register("periodic", PeriodicChecker(CheckFunc(func() error {
+		return errors.New("periodic down")
+	}), time.Millisecond))
+	for registry.Report().Checks["periodic"].Error == "" {
+		time.Sleep(time.Millisecond)
+	}
+	PublishExpvar("TestPublishExpvar", registry)
+
+	before := runs.Load()
+	var body statusBody
+	if err := json.Unmarshal([]byte(expvar.Get("TestPublishExpvar").String()), &body); err != nil {
+		t.Fatalf("unexpected error:%v", err)
+	}
+	if runs.Load() != before {
+		t.Errorf("check was not expected to run for expvar")
+	}
+	if body.Status != StatusUnhealthy || body.Checks["down"] != "down" || body.Checks["periodic"] != "periodic down" {
+		t.Errorf("unexpected published health: %+v", body)
+	}
+}
+
+// TestPublishExpvarUnevaluated ensures that checks never evaluated are
+// reported as such, and that skipped checks report their last result.
+func TestPublishExpvarUnevaluated(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("db", func() error { return errors.New("down") })
+	registry.Register("cache", CheckFunc(func() error { return nil }), WithDependsOn("db"))
+	registry.RegisterFunc("queue", func() error { return nil })
+	PublishExpvar("TestPublishExpvarUnevaluated", registry)
+
+	var body statusBody
+	if err := json.Unmarshal([]byte(expvar.Get("TestPublishExpvarUnevaluated").String()), &body); err != nil {
+		t.Fatalf("unexpected error:%v", err)
+	}
+	if body.Status != StatusHealthy || len(body.NotEvaluated) != 3 {
+		t.Errorf("expected every check to be reported as not evaluated: %+v", body)
+	}
+
+	queue := registry.lookup("queue")
+	registry.evaluateIf(context.Background(), func(rc *registeredCheck) bool {
+		return rc != queue
+	})
+	body = statusBody{}
+	if err := json.Unmarshal([]byte(expvar.Get("TestPublishExpvarUnevaluated").String()), &body); err != nil {
+		t.Fatalf("unexpected error:%v", err)
+	}
+	if body.Status != StatusUnhealthy || body.Checks["db"] != "down" {
+		t.Errorf("unexpected published health: %+v", body)
+	}
+	if _, ok := body.Warnings["cache"]; !ok {
+		t.Errorf("expected the skipped check to be reported: %+v", body)
+	}
+	if len(body.NotEvaluated) != 1 || body.NotEvaluated[0] != "queue" {
+		t.Errorf("expected queue to be reported as not evaluated: %+v", body)
+	}
+}
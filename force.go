@@ -0,0 +1,42 @@
+package health
+
+import "time"
+
+// ForceResult makes the named check report err, or success if err is nil,
+// for the given duration instead of running, after which it reports its
+// actual results again. This allows rehearsing failovers and alerting without
+// breaking the dependency the check probes. Forced results are flagged as such
+// in the Report and the responses of the status handlers. Forcing a result
+// again replaces the previous one; a non-positive duration cancels it.
+// ForceResult does nothing if no check is registered with name.
+func (registry *Registry) ForceResult(name string, err error, duration time.Duration) {
+	registry.mu.RLock()
+	rc, ok := registry.registeredChecks[name]
+	registry.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.forcedErr = err
+	rc.forcedUntil = time.Now().Add(duration)
+}
+
+// ForceResult makes the named check of the default registry report err for
+// the given duration.
+func ForceResult(name string, err error, duration time.Duration) {
+	DefaultRegistry.ForceResult(name, err, duration)
+}
+
+// forcedResult returns the result forced for the check and whether one is
+// currently in effect.
+func (rc *registeredCheck) forcedResult() (error, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if !time.Now().Before(rc.forcedUntil) {
+		return nil, false
+	}
+	return rc.forcedErr, true
+}
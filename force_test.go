@@ -0,0 +1,34 @@
+package health
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestForceResult ensures that a forced result overrides the check until it
+// expires.
+func TestForceResult(t *testing.T) {
+	registry := NewRegistry()
+	calls := 0
+	registry.RegisterFunc("db", func() error {
+		calls++
+		return nil
+	})
+
+	registry.ForceResult("db", errors.New("game day"), 20*time.Millisecond)
+	if status := registry.failingChecks(); status["db"] != "game day (forced)" {
+		t.Errorf("forced failure was expected: %v", status)
+	}
+	if cr := registry.Report().Checks["db"]; !cr.Forced || cr.Error != "game day" {
+		t.Errorf("forced failure was expected in the report: %+v", cr)
+	}
+	if calls != 0 {
+		t.Errorf("check was not expected to run while forced: %d calls", calls)
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if status := registry.failingChecks(); len(status) != 0 || calls != 1 {
+		t.Errorf("actual result was expected once the forced one expired: %v (%d calls)", status, calls)
+	}
+}
@@ -0,0 +1,126 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// GroupChecker returns a Checker evaluating checks concurrently and grading
+// the health of the group by the fraction of them failing. The group reports a
+// StatusError with SeverityWarning when more than degradeRatio of the checks
+// fail, and one with SeverityCritical when more than unhealthyRatio fail. The
+// error message includes the failing fraction along with the child errors.
+func GroupChecker(checks []Checker, degradeRatio, unhealthyRatio float64) Checker {
+	return &groupChecker{
+		checks:         checks,
+		degradeRatio:   degradeRatio,
+		unhealthyRatio: unhealthyRatio,
+	}
+}
+
+// groupChecker is the ContextChecker returned by GroupChecker.
+type groupChecker struct {
+	checks         []Checker
+	degradeRatio   float64
+	unhealthyRatio float64
+}
+
+// Check implements the Checker interface.
+func (g *groupChecker) Check() error {
+	return g.CheckContext(context.Background())
+}
+
+// CheckContext implements the ContextChecker interface.
+func (g *groupChecker) CheckContext(ctx context.Context) error {
+	errs := evaluateAll(ctx, g.checks)
+
+	var failures []string
+	for _, err := range errs {
+		if err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+
+	ratio := float64(len(failures)) / float64(len(g.checks))
+	var severity Severity
+	switch {
+	case ratio > g.unhealthyRatio:
+		severity = SeverityCritical
+	case ratio > g.degradeRatio:
+		severity = SeverityWarning
+	default:
+		return nil
+	}
+
+	return &StatusError{
+		Severity: severity,
+		Err: fmt.Errorf("%d of %d checks failing (%.0f%%): %s",
+			len(failures), len(g.checks), ratio*100, strings.Join(failures, "; ")),
+	}
+}
+
+// All returns a Checker evaluating checks concurrently and failing if any of
+// them fails, with the errors of the failing checks joined by errors.Join.
+func All(checks ...Checker) Checker {
+	return &combinedChecker{checks: checks}
+}
+
+// Any returns a Checker evaluating checks concurrently and passing if at least
+// one of them passes, e.g. when any replica of a dependency is enough. When
+// every check fails, their errors are joined by errors.Join. Any fails when
+// given no check.
+func Any(checks ...Checker) Checker {
+	return &combinedChecker{checks: checks, any: true}
+}
+
+// combinedChecker is the ContextChecker returned by All and Any.
+type combinedChecker struct {
+	checks []Checker
+	any    bool // whether a single passing check is enough
+}
+
+// Check implements the Checker interface.
+func (c *combinedChecker) Check() error {
+	return c.CheckContext(context.Background())
+}
+
+// CheckContext implements the ContextChecker interface.
+func (c *combinedChecker) CheckContext(ctx context.Context) error {
+	if c.any && len(c.checks) == 0 {
+		return errors.New("no check to pass")
+	}
+
+	errs := evaluateAll(ctx, c.checks)
+	if c.any {
+		for _, err := range errs {
+			if err == nil {
+				return nil
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// evaluateAll evaluates checks concurrently and returns their errors, in the
+// order of checks. A panicking check fails instead of crashing the process
+// from its goroutine.
+func evaluateAll(ctx context.Context, checks []Checker) []error {
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Checker) {
+			defer wg.Done()
+			errs[i] = evaluateSafely(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	return errs
+}
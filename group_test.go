@@ -0,0 +1,82 @@
+package health
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestGroupChecker ensures that the severity reported by a group depends on
+// the fraction of failing checks.
+func TestGroupChecker(t *testing.T) {
+	passing := CheckFunc(func() error { return nil })
+	failing := CheckFunc(func() error { return errors.New("replica down") })
+
+	for _, tc := range []struct {
+		failing  int
+		expected Severity
+	}{
+		{0, SeverityOK},
+		{1, SeverityOK},
+		{2, SeverityWarning},
+		{3, SeverityWarning},
+		{4, SeverityCritical},
+	} {
+		var checks []Checker
+		for i := 0; i < 4; i++ {
+			if i < tc.failing {
+				checks = append(checks, failing)
+			} else {
+				checks = append(checks, passing)
+			}
+		}
+
+		err := GroupChecker(checks, 0.25, 0.75).Check()
+		if severity := SeverityOf(err); severity != tc.expected {
+			t.Errorf("unexpected severity with %d of 4 failing: %v != %v (error:%v)", tc.failing, severity, tc.expected, err)
+		}
+	}
+}
+
+// TestAllAndAny ensures that All requires every check to pass and Any at
+// least one, joining the errors of the failing checks.
+func TestAllAndAny(t *testing.T) {
+	errFirst, errSecond := errors.New("first down"), errors.New("second down")
+	passing := CheckFunc(func() error { return nil })
+	first := CheckFunc(func() error { return errFirst })
+	second := CheckFunc(func() error { return errSecond })
+
+	if err := All(passing, passing).Check(); err != nil {
+		t.Errorf("unexpected error:%v", err)
+	}
+	if err := All(passing, first, second).Check(); !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Errorf("the errors of the failing checks were expected, error:%v", err)
+	}
+
+	if err := Any(first, passing).Check(); err != nil {
+		t.Errorf("unexpected error:%v", err)
+	}
+	if err := Any(first, second).Check(); !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Errorf("the errors of the failing checks were expected, error:%v", err)
+	}
+	if err := Any().Check(); err == nil {
+		t.Errorf("Any was expected to fail without checks")
+	}
+}
+
+// TestCompositePanic ensures that a panicking child of a composite check
+// fails the composite check instead of crashing the process.
+func TestCompositePanic(t *testing.T) {
+	passing := CheckFunc(func() error { return nil })
+	panicking := CheckFunc(func() error { panic("nil map") })
+
+	for name, check := range map[string]Checker{
+		"all":   All(passing, panicking),
+		"any":   Any(panicking),
+		"group": GroupChecker([]Checker{passing, panicking}, 0, 0),
+	} {
+		if err := check.Check(); err == nil || !strings.Contains(err.Error(), "nil map") {
+			t.Errorf("%s was expected to report the panic of its child, error:%v", name, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Package grpchealth integrates the health package with the gRPC Health
+// Checking Protocol (grpc.health.v1.Health). It lives in its own package so
+// that users of the health package do not depend on gRPC.
+package grpchealth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/docker/distribution/health"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCChecker returns a Checker querying the health of service through the
+// gRPC Health Checking Protocol on conn, failing unless it is SERVING. An
+// empty service name queries the overall health of the server. Each query is
+// bounded by timeout when it is positive.
+func GRPCChecker(conn grpc.ClientConnInterface, service string, timeout time.Duration) health.Checker {
+	return &grpcChecker{
+		client:  healthpb.NewHealthClient(conn),
+		service: service,
+		timeout: timeout,
+	}
+}
+
+// grpcChecker is the ContextChecker returned by GRPCChecker.
+type grpcChecker struct {
+	client  healthpb.HealthClient
+	service string
+	timeout time.Duration
+}
+
+// Check implements the health.Checker interface.
+func (gc *grpcChecker) Check() error {
+	return gc.CheckContext(context.Background())
+}
+
+// CheckContext implements the health.ContextChecker interface.
+func (gc *grpcChecker) CheckContext(ctx context.Context) error {
+	if gc.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, gc.timeout)
+		defer cancel()
+	}
+
+	target := "server"
+	if gc.service != "" {
+		target = fmt.Sprintf("service %q", gc.service)
+	}
+
+	resp, err := gc.client.Check(ctx, &healthpb.HealthCheckRequest{Service: gc.service})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return errors.New(target + " is unknown to the server (SERVICE_UNKNOWN)")
+		}
+		return errors.New("error checking " + target + ": " + err.Error())
+	}
+
+	switch s := resp.GetStatus(); s {
+	case healthpb.HealthCheckResponse_SERVING:
+		return nil
+	case healthpb.HealthCheckResponse_NOT_SERVING:
+		return errors.New(target + " is not serving (NOT_SERVING)")
+	case healthpb.HealthCheckResponse_SERVICE_UNKNOWN:
+		return errors.New(target + " is unknown to the server (SERVICE_UNKNOWN)")
+	default:
+		return errors.New(target + " reported status " + s.String())
+	}
+}
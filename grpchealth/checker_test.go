@@ -0,0 +1,63 @@
+package grpchealth
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial returns a connection to a gRPC server serving hs.
+func dial(t *testing.T, hs healthpb.HealthServer) *grpc.ClientConn {
+	l := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, hs)
+	go server.Serve(l)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("error dialing: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGRPCChecker(t *testing.T) {
+	hs := grpchealth.NewServer()
+	hs.SetServingStatus("db", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus("cache", healthpb.HealthCheckResponse_NOT_SERVING)
+	conn := dial(t, hs)
+
+	if err := GRPCChecker(conn, "", time.Second).Check(); err != nil {
+		t.Errorf("serving server was expected to pass, error:%v", err)
+	}
+
+	if err := GRPCChecker(conn, "db", time.Second).Check(); err != nil {
+		t.Errorf("serving service was expected to pass, error:%v", err)
+	}
+
+	if err := GRPCChecker(conn, "cache", time.Second).Check(); err == nil || !strings.Contains(err.Error(), "NOT_SERVING") {
+		t.Errorf("not serving service was expected to fail with NOT_SERVING, error:%v", err)
+	}
+
+	if err := GRPCChecker(conn, "queue", time.Second).Check(); err == nil || !strings.Contains(err.Error(), "SERVICE_UNKNOWN") {
+		t.Errorf("unknown service was expected to fail with SERVICE_UNKNOWN, error:%v", err)
+	}
+
+	hs.Shutdown()
+	if err := GRPCChecker(conn, "", time.Second).Check(); err == nil || !strings.Contains(err.Error(), "server is not serving") {
+		t.Errorf("shut down server was expected to fail, error:%v", err)
+	}
+}
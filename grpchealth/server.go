@@ -0,0 +1,148 @@
+package grpchealth
+
+import (
+	"context"
+	"sync"
+
+	"github.com/docker/distribution/health"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the gRPC Health Checking Protocol on top of a health
+// registry, so that gRPC clients such as Envoy can probe it. A service is
+// SERVING unless one of its checks fails critically, informational checks and
+// fail-open timeouts being ignored as in the handlers of the health package,
+// or while the registry is draining, see Registry.BeginDrain.
+type Server struct {
+	healthpb.UnimplementedHealthServer
+
+	registry *health.Registry
+	services map[string][]string
+
+	mu          sync.Mutex
+	watchers    map[chan struct{}]struct{}
+	unsubscribe func()
+}
+
+// NewServer returns a Server reporting the health of registry. The empty
+// service name covers every check of the registry, while services maps the
+// name of other services to the names of the checks they depend on. Other
+// service names are unknown.
+//
+// Watch streams are updated whenever a check of the registry transitions
+// between healthy and unhealthy; see health.Registry.OnStateChange for the
+// checks reporting their transitions. Close stops following the transitions
+// once the server is no longer needed.
+func NewServer(registry *health.Registry, services map[string][]string) *Server {
+	s := &Server{
+		registry: registry,
+		services: services,
+		watchers: make(map[chan struct{}]struct{}),
+	}
+	transitions, unsubscribe := registry.Subscribe()
+	s.unsubscribe = unsubscribe
+	go func() {
+		for range transitions {
+			s.notify()
+		}
+	}()
+	return s
+}
+
+// Close stops the server from following the transitions of the checks of its
+// registry, releasing it. Watch streams are no longer updated afterwards.
+func (s *Server) Close() {
+	s.unsubscribe()
+}
+
+// notify makes the watch streams re-evaluate their service.
+func (s *Server) notify() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for changed := range s.watchers {
+		select {
+		case changed <- struct{}{}:
+		default: // a re-evaluation is already pending
+		}
+	}
+}
+
+// Check implements the healthpb.HealthServer interface.
+func (s *Server) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st := s.status(ctx, req.GetService())
+	if st == healthpb.HealthCheckResponse_SERVICE_UNKNOWN {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// List implements the healthpb.HealthServer interface.
+func (s *Server) List(ctx context.Context, req *healthpb.HealthListRequest) (*healthpb.HealthListResponse, error) {
+	resp := &healthpb.HealthListResponse{Statuses: make(map[string]*healthpb.HealthCheckResponse)}
+	resp.Statuses[""] = &healthpb.HealthCheckResponse{Status: s.status(ctx, "")}
+	for service := range s.services {
+		resp.Statuses[service] = &healthpb.HealthCheckResponse{Status: s.status(ctx, service)}
+	}
+	return resp, nil
+}
+
+// Watch implements the healthpb.HealthServer interface. Unknown services are
+// reported as SERVICE_UNKNOWN, as required by the protocol.
+func (s *Server) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	changed := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.watchers[changed] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.watchers, changed)
+		s.mu.Unlock()
+	}()
+
+	ctx := stream.Context()
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		if st := s.status(ctx, req.GetService()); st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-changed:
+		}
+	}
+}
+
+// status evaluates the checks of service, or every check of the registry for
+// the overall health of the server.
+func (s *Server) status(ctx context.Context, service string) healthpb.HealthCheckResponse_ServingStatus {
+	var checks []string
+	if service != "" {
+		var ok bool
+		if checks, ok = s.services[service]; !ok {
+			return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+	}
+
+	if s.registry.Draining() {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	var report health.Report
+	if service == "" {
+		report = s.registry.ReportContext(ctx)
+	} else {
+		report = s.registry.ReportChecks(ctx, checks...)
+	}
+	for _, cr := range report.Checks {
+		if cr.Status == health.SeverityCritical.String() && !cr.Informational && !cr.FailedOpen {
+			return healthpb.HealthCheckResponse_NOT_SERVING
+		}
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
@@ -0,0 +1,114 @@
+package grpchealth
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/health"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer(t *testing.T) {
+	registry := health.NewRegistry()
+	db := health.NewStatusUpdater()
+	registry.Register("db", db)
+	registry.RegisterFunc("cache", func() error { return errors.New("down") })
+	conn := dial(t, NewServer(registry, map[string][]string{
+		"storage": {"db"},
+		"web":     {"cache", "db"},
+	}))
+	client := healthpb.NewHealthClient(conn)
+
+	if err := GRPCChecker(conn, "storage", time.Second).Check(); err != nil {
+		t.Errorf("storage was expected to be serving, error:%v", err)
+	}
+	for _, service := range []string{"", "web"} {
+		resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil || resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Errorf("%q was expected not to be serving: %v, error:%v", service, resp, err)
+		}
+	}
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "queue"}); status.Code(err) != codes.NotFound {
+		t.Errorf("unknown service was expected to be not found, error:%v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "storage"})
+	if err != nil {
+		t.Fatalf("error watching: %v", err)
+	}
+	db.Update(nil)
+	if resp, err := stream.Recv(); err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("storage was expected to be serving: %v, error:%v", resp, err)
+	}
+	db.Update(errors.New("down"))
+	if resp, err := stream.Recv(); err != nil || resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("storage was expected to stop serving: %v, error:%v", resp, err)
+	}
+}
+
+func TestServerDraining(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.RegisterFunc("db", func() error { return nil })
+	conn := dial(t, NewServer(registry, map[string][]string{"storage": {"db"}}))
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "storage"})
+	if err != nil {
+		t.Fatalf("error watching: %v", err)
+	}
+	if resp, err := stream.Recv(); err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("storage was expected to be serving: %v, error:%v", resp, err)
+	}
+
+	registry.BeginDrain()
+	if resp, err := stream.Recv(); err != nil || resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("storage was expected to stop serving while draining: %v, error:%v", resp, err)
+	}
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil || resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("server was expected not to be serving while draining: %v, error:%v", resp, err)
+	}
+}
+
+func TestServerScoped(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.RegisterFunc("db", func() error { return nil })
+	var evaluated int32
+	registry.RegisterFunc("cache", func() error {
+		atomic.AddInt32(&evaluated, 1)
+		return errors.New("down")
+	})
+	s := NewServer(registry, map[string][]string{"storage": {"db"}})
+	defer s.Close()
+	client := healthpb.NewHealthClient(dial(t, s))
+
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "storage"})
+	if err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("storage was expected to be serving: %v, error:%v", resp, err)
+	}
+	if n := atomic.LoadInt32(&evaluated); n != 0 {
+		t.Errorf("checks outside of storage were evaluated %d times", n)
+	}
+}
+
+func TestServerClose(t *testing.T) {
+	registry := health.NewRegistry()
+	s := NewServer(registry, nil)
+	s.Close()
+	s.Close()
+
+	// transitions are no longer followed once closed
+	db := health.NewStatusUpdater()
+	registry.Register("db", db)
+	db.Update(errors.New("down"))
+	registry.Report()
+}
@@ -0,0 +1,97 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// HeadlinePolicy selects which failing check is highlighted as the headline
+// failure of a registry, by Reason and in the body of the 503 responses of
+// Handler, see WithFailureDetail.
+type HeadlinePolicy int
+
+const (
+	// HeadlinePriority, the default, highlights the most severe failure:
+	// failures of critical severity come before warnings, and failures of
+	// equal severity are ordered by check name.
+	HeadlinePriority HeadlinePolicy = iota
+
+	// HeadlineLatest highlights the check that most recently became
+	// unhealthy, according to the transitions recorded by the registry (see
+	// RecentEvents). Failing checks without a recorded transition, such as
+	// checks evaluated on demand, come after those with one, ordered as with
+	// HeadlinePriority.
+	HeadlineLatest
+)
+
+// SetHeadlinePolicy sets the policy used to pick the headline failure of the
+// registry.
+func (registry *Registry) SetHeadlinePolicy(policy HeadlinePolicy) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.headlinePolicy = policy
+}
+
+// Reason evaluates every check of the registry and returns its headline
+// failure, picked according to the headline policy of the registry, as
+// "name: error". It returns an empty string when no check affecting the
+// overall health fails.
+func (registry *Registry) Reason() string {
+	return registry.headline(registry.evaluate(context.Background()))
+}
+
+// Reason returns the headline failure of the default registry.
+func Reason() string {
+	return DefaultRegistry.Reason()
+}
+
+// headline returns the headline failure among results.
+func (registry *Registry) headline(results map[string]result) string {
+	registry.mu.RLock()
+	policy := registry.headlinePolicy
+	registry.mu.RUnlock()
+
+	var names []string
+	for name, r := range results {
+		if r.err != nil && r.affectsHealth() {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+
+	var failedAt map[string]time.Time
+	if policy == HeadlineLatest {
+		failedAt = registry.lastFailures()
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ti, tj := failedAt[names[i]], failedAt[names[j]]
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		si, sj := SeverityOf(results[names[i]].err), SeverityOf(results[names[j]].err)
+		if si != sj {
+			return si > sj
+		}
+		return names[i] < names[j]
+	})
+
+	return names[0] + ": " + registry.errorMessage(results[names[0]].err)
+}
+
+// lastFailures returns when each check last became unhealthy, among the
+// recorded transitions.
+func (registry *Registry) lastFailures() map[string]time.Time {
+	registry.eventsMu.Lock()
+	defer registry.eventsMu.Unlock()
+
+	failedAt := make(map[string]time.Time)
+	for _, e := range registry.events {
+		if !e.Healthy {
+			failedAt[e.Name] = e.Time
+		}
+	}
+	return failedAt
+}
@@ -0,0 +1,39 @@
+package health
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestReason ensures that the headline failure is picked according to the
+// headline policy of the registry.
+func TestReason(t *testing.T) {
+	registry := NewRegistry()
+	if reason := registry.Reason(); reason != "" {
+		t.Errorf("a healthy registry was not expected to have a reason: %q", reason)
+	}
+
+	first, second := NewStatusUpdater(), NewStatusUpdater()
+	registry.Register("b-first", first)
+	registry.Register("c-second", second)
+	registry.RegisterFunc("a-warning", func() error {
+		return &StatusError{Severity: SeverityWarning, Err: errors.New("slow")}
+	})
+	registry.RegisterInformational("d-informational", CheckFunc(func() error { return errors.New("ignored") }))
+
+	first.Update(nil)
+	second.Update(nil)
+	first.Update(errors.New("first down"))
+	time.Sleep(time.Millisecond)
+	second.Update(errors.New("second down"))
+
+	if reason := registry.Reason(); reason != "b-first: first down" {
+		t.Errorf("unexpected reason with the priority policy: %q", reason)
+	}
+
+	registry.SetHeadlinePolicy(HeadlineLatest)
+	if reason := registry.Reason(); reason != "c-second: second down" {
+		t.Errorf("unexpected reason with the latest policy: %q", reason)
+	}
+}
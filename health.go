@@ -1,13 +1,23 @@
 package health
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
-	"github.com/docker/distribution/context"
+	dcontext "github.com/docker/distribution/context"
 	"github.com/docker/distribution/registry/api/errcode"
 )
 
@@ -15,8 +25,138 @@ import (
 // registry defined in DefaultRegistry. However, unit tests may need to create
 // separate registries to isolate themselves from other tests.
 type Registry struct {
-	mu               sync.RWMutex
-	registeredChecks map[string]Checker
+	mu                  sync.RWMutex
+	registeredChecks    map[string]*registeredCheck
+	defaultTimeout      time.Duration
+	maxErrorLength      int
+	headlinePolicy      HeadlinePolicy
+	scoreThreshold      float64          // score below which the registry is unhealthy, see SetScoreThreshold
+	stalenessMultiplier float64          // see SetStalenessMultiplier
+	pool                *Pool            // evaluates the checks when set
+	renderer            ResponseRenderer // writes the bodies of the status handlers when set
+	included            []includedRegistry
+
+	eventsMu      sync.Mutex
+	events        []StateChange        // oldest first
+	stateSince    map[string]time.Time // last transition or registration of each check
+	transitions   map[string]uint64    // number of transitions of each check
+	flapWindow    time.Duration
+	flapThreshold int
+	logger        *slog.Logger
+	onStateChange []func(name string, healthy bool, err error)
+	subscriptions map[*subscription]struct{}
+
+	inflightMu sync.Mutex
+	inflight   map[*evaluation]struct{}
+	queued     atomic.Int64 // checks waiting in evaluations in progress
+
+	paused   bool // whether periodic checks are paused, guarded by mu
+	draining bool // see BeginDrain, guarded by mu
+
+	// middlewareMu is apart from mu, which evaluations hold while running
+	// checks
+	middlewareMu sync.RWMutex
+	middleware   []Middleware
+}
+
+// evaluation is a single in-progress invocation of a registered check.
+type evaluation struct {
+	name  string
+	start time.Time
+}
+
+// registeredCheck is a Checker together with the options it was registered
+// with.
+type registeredCheck struct {
+	checker       Checker
+	timeout       time.Duration
+	timeoutPolicy TimeoutPolicy
+	informational bool // reported but never affecting the overall health
+	readiness     bool // left out of liveness probes
+	groups        []string
+	owner         string  // team owning the check, see WithOwner
+	weight        float64 // share of the health score, see WithWeight
+	dependsOn     []string
+	detached      atomic.Bool // set once unregistered or replaced
+
+	mu          sync.Mutex
+	lastPanic   string // stack trace of the last panic of the check
+	invocations uint64
+	lastTrigger string
+	forcedErr   error
+	forcedUntil time.Time
+	muted       bool   // see SetEnabled
+	last        result // result of the last evaluation, see PublishExpvar
+	evaluated   bool   // whether last is set
+}
+
+// timeoutOr returns the timeout of the check, or def if it has none.
+func (rc *registeredCheck) timeoutOr(def time.Duration) time.Duration {
+	if rc.timeout == 0 {
+		return def
+	}
+	return rc.timeout
+}
+
+// CheckOption configures how a registered check is evaluated.
+type CheckOption func(*registeredCheck)
+
+// WithTimeout bounds every evaluation of the check to d, overriding the
+// default timeout of the registry. A check still running when d elapses is
+// reported as failed with ErrTimeout.
+func WithTimeout(d time.Duration) CheckOption {
+	return func(rc *registeredCheck) {
+		rc.timeout = d
+	}
+}
+
+// TimeoutPolicy is the disposition of a check that timed out.
+type TimeoutPolicy int
+
+const (
+	// FailClosed reports a check that timed out as failed. This is the
+	// default, suited to critical checks.
+	FailClosed TimeoutPolicy = iota
+
+	// FailOpen reports the timeout of a check without it affecting the
+	// overall health, so that a slow non-critical check cannot cause an
+	// outage. Other failures of the check still do.
+	FailOpen
+)
+
+// WithTimeoutPolicy sets the disposition of the check when it times out.
+func WithTimeoutPolicy(policy TimeoutPolicy) CheckOption {
+	return func(rc *registeredCheck) {
+		rc.timeoutPolicy = policy
+	}
+}
+
+// WithOwner annotates the check with the team owning it, so that alerting can
+// route its failures. The owner appears in the Report and the state changes of
+// the check, and never affects the health computation.
+func WithOwner(owner string) CheckOption {
+	return func(rc *registeredCheck) {
+		rc.owner = owner
+	}
+}
+
+// WithGroup adds the check to the named group, whose checks StatusHandler
+// reports at /debug/health/<group>. A check can belong to several groups.
+// The group "all" is reserved for every check.
+func WithGroup(group string) CheckOption {
+	return func(rc *registeredCheck) {
+		rc.groups = append(rc.groups, group)
+	}
+}
+
+// inGroup reports whether the check belongs to group.
+func (rc *registeredCheck) inGroup(group string) bool {
+	for _, g := range rc.groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
 }
 
 // NewRegistry creates a new registry. This isn't necessary for normal use of
@@ -24,7 +164,14 @@ type Registry struct {
 // own set of checks.
 func NewRegistry() *Registry {
 	return &Registry{
-		registeredChecks: make(map[string]Checker),
+		registeredChecks:    make(map[string]*registeredCheck),
+		inflight:            make(map[*evaluation]struct{}),
+		stateSince:          make(map[string]time.Time),
+		transitions:         make(map[string]uint64),
+		maxErrorLength:      defaultMaxErrorLength,
+		flapWindow:          defaultFlapWindow,
+		flapThreshold:       defaultFlapThreshold,
+		stalenessMultiplier: defaultStalenessMultiplier,
 	}
 }
 
@@ -38,6 +185,21 @@ type Checker interface {
 	Check() error
 }
 
+// ContextChecker is implemented by checkers that can abandon their work when
+// a context is cancelled. The registry calls CheckContext instead of Check,
+// with a context carrying the deadline of the check when a timeout applies.
+type ContextChecker interface {
+	Checker
+
+	// CheckContext returns nil if the service is okay. It should return
+	// promptly once ctx is done.
+	CheckContext(ctx context.Context) error
+}
+
+// ErrTimeout is reported for checks that did not complete within their
+// timeout.
+var ErrTimeout = errors.New("health check timed out")
+
 // CheckFunc is a convenience type to create functions that implement
 // the Checker interface
 type CheckFunc func() error
@@ -48,6 +210,22 @@ func (cf CheckFunc) Check() error {
 	return cf()
 }
 
+// CheckFuncContext is a convenience type to create functions that implement
+// the ContextChecker interface, for checks which should abandon their work
+// once their deadline expires.
+type CheckFuncContext func(ctx context.Context) error
+
+// Check implements the Checker interface, calling the function with a
+// background context.
+func (cf CheckFuncContext) Check() error {
+	return cf(context.Background())
+}
+
+// CheckContext implements the ContextChecker interface.
+func (cf CheckFuncContext) CheckContext(ctx context.Context) error {
+	return cf(ctx)
+}
+
 // Updater implements a health check that is explicitly set.
 type Updater interface {
 	Checker
@@ -61,8 +239,11 @@ type Updater interface {
 // This allows us to have a Checker that returns the Check() call immediately
 // not blocking on a potentially expensive check.
 type updater struct {
-	mu     sync.Mutex
-	status error
+	mu          sync.Mutex
+	status      error
+	updated     bool      // whether the status was ever updated
+	lastUpdated time.Time // when the status was last updated, zero until then
+	listeners   []func(status error)
 }
 
 // Check implements the Checker interface
@@ -76,14 +257,60 @@ func (u *updater) Check() error {
 // Update implements the Updater interface, allowing asynchronous access to
 // the status of a Checker.
 func (u *updater) Update(status error) {
+	u.mu.Lock()
+	// the first update establishes the state rather than changing it
+	changed := u.updated && (u.status == nil) != (status == nil)
+	u.updated = true
+	u.status = status
+	u.lastUpdated = time.Now()
+	listeners := u.listeners
+	u.mu.Unlock()
+
+	if changed {
+		notify(listeners, status)
+	}
+}
+
+// LastError implements the StatusUpdater interface.
+func (u *updater) LastError() error {
 	u.mu.Lock()
 	defer u.mu.Unlock()
 
-	u.status = status
+	return u.status
+}
+
+// LastUpdated implements the StatusUpdater interface.
+func (u *updater) LastUpdated() time.Time {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.lastUpdated
+}
+
+// notifyTransitions implements the transitionNotifier interface.
+func (u *updater) notifyTransitions(listener func(status error)) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.listeners = append(u.listeners, listener)
+}
+
+// StatusUpdater is an Updater which also tells what it was last updated with
+// and when, such as to show how stale a manually driven check is. Its methods
+// are safe to call concurrently with Update.
+type StatusUpdater interface {
+	Updater
+
+	// LastError returns the status of the last update, nil until the first.
+	LastError() error
+
+	// LastUpdated returns when the status was last updated, the zero Time
+	// until the first update.
+	LastUpdated() time.Time
 }
 
 // NewStatusUpdater returns a new updater
-func NewStatusUpdater() Updater {
+func NewStatusUpdater() StatusUpdater {
 	return &updater{}
 }
 
@@ -94,8 +321,11 @@ func NewStatusUpdater() Updater {
 type thresholdUpdater struct {
 	mu        sync.Mutex
 	status    error
+	name      string // prefix of the errors, if any
 	threshold int
 	count     int
+	updated   bool // whether the status was ever updated
+	listeners []func(status error)
 }
 
 // Check implements the Checker interface
@@ -103,7 +333,15 @@ func (tu *thresholdUpdater) Check() error {
 	tu.mu.Lock()
 	defer tu.mu.Unlock()
 
+	return tu.current()
+}
+
+// current returns the status reported by Check. The caller must hold tu.mu.
+func (tu *thresholdUpdater) current() error {
 	if tu.count >= tu.threshold {
+		if tu.name != "" {
+			return fmt.Errorf("%s: %w", tu.name, tu.status)
+		}
 		return tu.status
 	}
 
@@ -114,8 +352,7 @@ func (tu *thresholdUpdater) Check() error {
 // access to the status of a Checker.
 func (tu *thresholdUpdater) Update(status error) {
 	tu.mu.Lock()
-	defer tu.mu.Unlock()
-
+	before := tu.current()
 	if status == nil {
 		tu.count = 0
 	} else if tu.count < tu.threshold {
@@ -123,6 +360,24 @@ func (tu *thresholdUpdater) Update(status error) {
 	}
 
 	tu.status = status
+	after := tu.current()
+	// the first update establishes the state rather than changing it
+	changed := tu.updated && (before == nil) != (after == nil)
+	tu.updated = true
+	listeners := tu.listeners
+	tu.mu.Unlock()
+
+	if changed {
+		notify(listeners, after)
+	}
+}
+
+// notifyTransitions implements the transitionNotifier interface.
+func (tu *thresholdUpdater) notifyTransitions(listener func(status error)) {
+	tu.mu.Lock()
+	defer tu.mu.Unlock()
+
+	tu.listeners = append(tu.listeners, listener)
 }
 
 // NewThresholdStatusUpdater returns a new thresholdUpdater
@@ -130,86 +385,957 @@ func NewThresholdStatusUpdater(t int) Updater {
 	return &thresholdUpdater{threshold: t}
 }
 
+// NewNamedThresholdStatusUpdater returns a threshold updater like
+// NewThresholdStatusUpdater whose errors are prefixed with name, e.g.
+// "replica-3: connection refused", telling apart updaters failing with
+// similar errors in aggregated output. Errors are wrapped, so errors.Is and
+// errors.As still match the updates.
+func NewNamedThresholdStatusUpdater(name string, t int) Updater {
+	return &thresholdUpdater{name: name, threshold: t}
+}
+
+// StoppableChecker is a Checker running in a goroutine of its own, such as
+// the checkers returned by PeriodicChecker.
+type StoppableChecker interface {
+	Checker
+
+	// Stop halts the goroutine of the checker, which then keeps reporting
+	// the last observed result. A run of the check in progress still
+	// completes, but no other run starts. Stop is idempotent and safe for
+	// concurrent use.
+	Stop()
+}
+
+// LastRunner is implemented by checkers running their check in the background,
+// such as those returned by PeriodicChecker and its variants, and by
+// DrivenChecker. A last run growing stale reveals a checker that stopped
+// running, e.g. after Stop. The time of the last run is also the Timestamp
+// of the results of these checkers in CheckStatus.
+type LastRunner interface {
+	// LastRun returns when the check last ran, the zero Time until its
+	// first run.
+	LastRun() time.Time
+}
+
 // PeriodicChecker wraps an updater to provide a periodic checker
-func PeriodicChecker(check Checker, period time.Duration) Checker {
-	u := NewStatusUpdater()
-	go func() {
-		t := time.NewTicker(period)
-		for {
-			<-t.C
-			u.Update(check.Check())
-		}
-	}()
+func PeriodicChecker(check Checker, period time.Duration) StoppableChecker {
+	return PeriodicCheckerContext(context.Background(), check, period)
+}
+
+// PeriodicCheckerContext wraps an updater to provide a periodic checker whose
+// goroutine exits once ctx is done. The checker then keeps reporting the last
+// observed result.
+func PeriodicCheckerContext(ctx context.Context, check Checker, period time.Duration) StoppableChecker {
+	ctx, cancel := context.WithCancel(ctx)
+	pc := newPeriodicChecker(ctx, cancel, NewStatusUpdater(), check, period)
+	go pc.run(ctx, period)
 
-	return u
+	return pc
 }
 
 // PeriodicThresholdChecker wraps an updater to provide a periodic checker that
 // uses a threshold before it changes status
-func PeriodicThresholdChecker(check Checker, period time.Duration, threshold int) Checker {
-	tu := NewThresholdStatusUpdater(threshold)
-	go func() {
-		t := time.NewTicker(period)
-		for {
-			<-t.C
-			tu.Update(check.Check())
+func PeriodicThresholdChecker(check Checker, period time.Duration, threshold int) StoppableChecker {
+	ctx, cancel := context.WithCancel(context.Background())
+	pc := newPeriodicChecker(ctx, cancel, NewThresholdStatusUpdater(threshold), check, period)
+	go pc.run(ctx, period)
+
+	return pc
+}
+
+// PeriodicCheckerWithJitter wraps an updater to provide a periodic checker
+// whose runs are spread randomly within ±jitter of period, e.g. 0.25 for ±25%,
+// so that instances started together do not probe shared dependencies in
+// lockstep. Unlike PeriodicChecker, the check first runs right away, keeping
+// the window during which the checker has no result short. The jitter is
+// clamped between 0 and 1.
+func PeriodicCheckerWithJitter(check Checker, period time.Duration, jitter float64) StoppableChecker {
+	jitter = math.Max(0, math.Min(jitter, 1))
+	ctx, cancel := context.WithCancel(context.Background())
+	pc := newPeriodicChecker(ctx, cancel, NewStatusUpdater(), check, time.Duration(float64(period)*(1+jitter)))
+	go pc.runJittered(ctx, period, jitter)
+
+	return pc
+}
+
+// PeriodicBackoffChecker wraps an updater to provide a periodic checker which
+// backs off while the check fails, so as not to add load to a dependency that
+// is down. The interval between runs starts at base and doubles after every
+// consecutive failure, up to max, and returns to base on the first success.
+// Check reports the latest result, as for PeriodicChecker.
+func PeriodicBackoffChecker(check Checker, base, max time.Duration) StoppableChecker {
+	if max < base {
+		max = base
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	pc := newPeriodicChecker(ctx, cancel, NewStatusUpdater(), check, max)
+	go pc.runBackoff(ctx, base, max)
+
+	return pc
+}
+
+// newPeriodicChecker returns a periodic checker of check whose goroutine runs
+// until ctx, cancelled by cancel, is done, with at most maxGap between runs.
+func newPeriodicChecker(ctx context.Context, cancel context.CancelFunc, updater Updater, check Checker, maxGap time.Duration) *periodicChecker {
+	return &periodicChecker{
+		updater: updater,
+		check:   check,
+		cancel:  cancel,
+		done:    ctx.Done(),
+		maxGap:  maxGap,
+		started: time.Now(),
+	}
+}
+
+// periodicChecker is the Checker returned by the periodic and driven checker
+// constructors. It reports the result held by its updater, which is refreshed
+// on every tick of its goroutine, or of Registry.Tick for driven checkers,
+// unless the checker is paused.
+type periodicChecker struct {
+	updater Updater
+	check   Checker
+	driven  bool               // whether the checker runs on Registry.Tick rather than a goroutine
+	cancel  context.CancelFunc // stops the goroutine, nil for driven checkers
+	done    <-chan struct{}    // closed once the goroutine stops, nil for driven checkers
+	maxGap  time.Duration      // longest expected time between runs, zero for driven checkers
+	started time.Time
+	paused  atomic.Bool
+
+	mu           sync.Mutex
+	runListeners []func()
+	lastRun      time.Time     // when the updater was last updated, zero until then
+	lastStart    time.Time     // when the last run, possibly in progress, started
+	lastDuration time.Duration // how long the last completed run took
+}
+
+// Check implements the Checker interface
+func (pc *periodicChecker) Check() error {
+	return pc.updater.Check()
+}
+
+// notifyTransitions implements the transitionNotifier interface.
+func (pc *periodicChecker) notifyTransitions(listener func(status error)) {
+	if tn, ok := pc.updater.(transitionNotifier); ok {
+		tn.notifyTransitions(listener)
+	}
+}
+
+// notifyRuns implements the runNotifier interface.
+func (pc *periodicChecker) notifyRuns(listener func()) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.runListeners = append(pc.runListeners, listener)
+}
+
+// setPaused implements the pausable interface.
+func (pc *periodicChecker) setPaused(paused bool) {
+	pc.paused.Store(paused)
+}
+
+// isPaused implements the pausable interface.
+func (pc *periodicChecker) isPaused() bool {
+	return pc.paused.Load()
+}
+
+// run updates the result of the checker every period until ctx is done.
+func (pc *periodicChecker) run(ctx context.Context, period time.Duration) {
+	t := time.NewTicker(period)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if ctx.Err() != nil {
+				return // select does not favor ctx when both are ready
+			}
+			pc.tick()
 		}
-	}()
+	}
+}
+
+// LastRun implements the LastRunner interface.
+func (pc *periodicChecker) LastRun() time.Time {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	return pc.lastRun
+}
+
+// resultTime implements the cachedResult interface.
+func (pc *periodicChecker) resultTime() time.Time {
+	return pc.LastRun()
+}
+
+// Stop implements the StoppableChecker interface. It does nothing for driven
+// checkers, which have no goroutine.
+func (pc *periodicChecker) Stop() {
+	if pc.cancel != nil {
+		pc.cancel()
+	}
+}
+
+// runJittered runs the check right away, then after every period randomized
+// within ±jitter, until ctx is done.
+func (pc *periodicChecker) runJittered(ctx context.Context, period time.Duration, jitter float64) {
+	for {
+		pc.tick()
+
+		delay := time.Duration(float64(period) * (1 + jitter*(2*rand.Float64()-1)))
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}
+}
 
-	return tu
+// runBackoff runs the check after base, then after an interval doubling with
+// every consecutive failure up to max, until ctx is done.
+func (pc *periodicChecker) runBackoff(ctx context.Context, base, max time.Duration) {
+	delay := base
+	for {
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+			if ctx.Err() != nil {
+				return
+			}
+		}
+
+		pc.tick()
+		if pc.updater.Check() == nil {
+			delay = base
+		} else if delay = 2 * delay; delay > max {
+			delay = max
+		}
+	}
 }
 
-// CheckStatus returns a map with all the current health check errors
-func (registry *Registry) CheckStatus() map[string]string { // TODO(stevvooe) this needs a proper type
+// tick runs the check and updates the result of the checker, unless it is
+// paused.
+func (pc *periodicChecker) tick() {
+	if pc.isPaused() {
+		return
+	}
+
+	start := time.Now()
+	pc.mu.Lock()
+	pc.lastStart = start
+	pc.mu.Unlock()
+
+	// a panic would otherwise crash the process from the goroutine of the
+	// checker, or from Registry.Tick
+	pc.updater.Update(evaluateSafely(context.Background(), pc.check))
+
+	pc.mu.Lock()
+	pc.lastRun = time.Now()
+	pc.lastDuration = pc.lastRun.Sub(start)
+	listeners := pc.runListeners
+	pc.mu.Unlock()
+	for _, listener := range listeners {
+		listener()
+	}
+}
+
+// SetDefaultTimeout bounds the evaluation of every check in the registry that
+// was not registered with its own timeout through WithTimeout. A zero duration,
+// the default, leaves such checks unbounded.
+//
+// Checks implementing ContextChecker receive a context whose deadline is the
+// effective timeout, allowing them to stop their work early. Other checks are
+// abandoned when the timeout expires: they are reported as failed but keep
+// running in the background until they return on their own.
+func (registry *Registry) SetDefaultTimeout(d time.Duration) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.defaultTimeout = d
+}
+
+// SetDefaultTimeout bounds the evaluation of every check in the default
+// registry that was not registered with its own timeout, so that a hung check
+// cannot block StatusHandler. Abandoned checks are not forcibly stopped.
+func SetDefaultTimeout(d time.Duration) {
+	DefaultRegistry.SetDefaultTimeout(d)
+}
+
+// defaultMaxErrorLength is the default maximum length, in bytes, of the error
+// messages reported by a registry.
+const defaultMaxErrorLength = 1024
+
+// SetMaxErrorLength bounds the length, in bytes, of the check error messages
+// reported by the registry, defaulting to 1KB. Longer messages are cut and
+// suffixed with an ellipsis in every output: Report, the HTTP handlers,
+// NagiosOutput and TraceHandler. The errors returned by CheckStatus are left
+// untouched. A zero or negative n lifts the limit.
+func (registry *Registry) SetMaxErrorLength(n int) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.maxErrorLength = n
+}
+
+// errorMessage returns the message of err, truncated to the maximum error
+// length of the registry.
+func (registry *Registry) errorMessage(err error) string {
 	registry.mu.RLock()
-	defer registry.mu.RUnlock()
+	max := registry.maxErrorLength
+	registry.mu.RUnlock()
+
+	msg := err.Error()
+	if max <= 0 || len(msg) <= max {
+		return msg
+	}
+	// Avoid cutting a multi-byte character in half.
+	for max > 0 && !utf8.RuneStart(msg[max]) {
+		max--
+	}
+	return msg[:max] + "..."
+}
+
+// CheckResult is the result of a single check.
+type CheckResult struct {
+	// Name is the name the check was registered with.
+	Name string
+
+	// Err is the error returned by the check, nil if it passed.
+	Err error
+
+	// Timestamp is when the result was produced. For checks reporting a
+	// result produced in the background, such as periodic checks, it is
+	// when that result was last refreshed, and zero until the first one is
+	// available.
+	Timestamp time.Time
+}
+
+// CheckStatus runs every check of the registry and returns their results,
+// passing or not, keyed by check name. Results include informational checks
+// and fail-open timeouts, which do not affect the overall health; see Report
+// for these details.
+func (registry *Registry) CheckStatus() map[string]CheckResult {
+	results := registry.evaluate(context.Background())
+	status := make(map[string]CheckResult, len(results))
+	for name, r := range results {
+		status[name] = CheckResult{Name: name, Err: r.err, Timestamp: r.timestamp}
+	}
+
+	return status
+}
+
+// failedCritically reports whether any of the results affecting the overall
+// health failed with SeverityCritical.
+func failedCritically(results map[string]result) bool {
+	for _, r := range results {
+		if r.affectsHealth() && SeverityOf(r.err) == SeverityCritical {
+			return true
+		}
+	}
+	return false
+}
+
+// failingChecks runs every check of the registry and returns the error
+// messages of the failures affecting the overall health, keyed by check name.
+func (registry *Registry) failingChecks() map[string]string {
+	return registry.failures(registry.evaluate(context.Background()))
+}
+
+// failures returns the error messages of the results affecting the overall
+// health that failed, keyed by check name.
+func (registry *Registry) failures(results map[string]result) map[string]string {
 	statusKeys := make(map[string]string)
-	for k, v := range registry.registeredChecks {
-		err := v.Check()
-		if err != nil {
-			statusKeys[k] = err.Error()
+	for k, r := range results {
+		if r.err != nil && r.affectsHealth() {
+			statusKeys[k] = registry.errorMessage(r.err)
+			if r.forced {
+				statusKeys[k] += " (forced)"
+			}
 		}
 	}
 
 	return statusKeys
 }
 
-// CheckStatus returns a map with all the current health check errors from the
-// default registry.
-func CheckStatus() map[string]string {
+// result is the outcome of evaluating a registered check.
+type result struct {
+	err           error // nil for a passing check
+	informational bool
+	owner         string
+	weight        float64
+	failedOpen    bool // whether err is a timeout of a FailOpen check
+	paused        bool // whether the check is a paused periodic check
+	forced        bool // whether err was forced with ForceResult
+	skipped       bool // whether a dependency of the check failed, see WithDependsOn
+	muted         bool // whether the check was muted with SetEnabled
+	draining      bool // whether the result is the failure of draining, see BeginDrain
+	unevaluated   bool // whether the check was never evaluated, see PublishExpvar
+	invocations   uint64
+	lastTrigger   string
+	age           time.Duration // age of a cached result
+	duration      time.Duration // how long the check ran in this evaluation
+	timestamp     time.Time     // when the result was produced
+}
+
+// affectsHealth reports whether the result is taken into account for the
+// overall health.
+func (r result) affectsHealth() bool {
+	return !r.informational && !r.failedOpen && !r.muted && !r.unevaluated
+}
+
+// evaluate runs every check of the registry concurrently and returns their
+// results.
+func (registry *Registry) evaluate(ctx context.Context) map[string]result {
+	return registry.evaluateIf(ctx, nil)
+}
+
+// evaluateIf runs the checks of the registry for which include returns true,
+// or every check if include is nil, concurrently and returns their results.
+func (registry *Registry) evaluateIf(ctx context.Context, include func(rc *registeredCheck) bool) map[string]result {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	ctx = newEvaluation(ctx)
+
+	checks := registry.registeredChecks
+	if include != nil {
+		checks = make(map[string]*registeredCheck, len(registry.registeredChecks))
+		for k, v := range registry.registeredChecks {
+			if include(v) {
+				checks[k] = v
+			}
+		}
+	}
+	registry.queued.Add(int64(len(checks)))
+
+	// checks run concurrently, so that an evaluation takes as long as the
+	// slowest check rather than the sum of all checks
+	spawn := func(task func()) { go task() }
+	if pool := registry.pool; pool != nil && !onPool(ctx, pool) {
+		spawn = pool.submit
+		ctx = context.WithValue(ctx, poolKey{}, pool)
+	}
+
+	// checks wait for the checks they depend on to complete first
+	done := make(map[string]chan struct{}, len(checks))
+	for k := range checks {
+		done[k] = make(chan struct{})
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]result, len(checks))
+	)
+	for k, v := range checks {
+		k, v := k, v
+		var deps []string
+		for _, dep := range v.dependsOn {
+			if _, ok := done[dep]; ok {
+				deps = append(deps, dep)
+			}
+		}
+
+		task := func() {
+			defer wg.Done()
+			defer close(done[k])
+			registry.queued.Add(-1)
+
+			var r result
+			skipped := false
+			mu.Lock()
+			for _, dep := range deps {
+				// skipping is transitive
+				if d := results[dep]; d.skipped || SeverityOf(d.err) == SeverityCritical {
+					r, skipped = skippedResult(v, dep, d.skipped), true
+					break
+				}
+			}
+			mu.Unlock()
+			if !skipped {
+				r = registry.evaluateCheck(ctx, k, v)
+			} else {
+				v.setLastResult(r)
+			}
+			mu.Lock()
+			results[k] = r
+			mu.Unlock()
+		}
+
+		wg.Add(1)
+		if len(deps) == 0 {
+			spawn(task)
+			continue
+		}
+		// wait outside of the pool, so that a dependent check does not
+		// hold a goroutine of the pool its dependencies need
+		go func() {
+			for _, dep := range deps {
+				<-done[dep]
+			}
+			spawn(task)
+		}()
+	}
+	for _, child := range registry.included {
+		child := child
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			childResults := child.registry.evaluateIf(ctx, include)
+			mu.Lock()
+			defer mu.Unlock()
+			for name, r := range childResults {
+				results[child.prefix+"/"+name] = r
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// evaluateCheck runs the named check as part of the evaluation carried by ctx
+// and returns its result. The caller must hold registry.mu.
+func (registry *Registry) evaluateCheck(ctx context.Context, name string, rc *registeredCheck) result {
+	muted := rc.isMuted()
+	err, forced := rc.forcedResult()
+	var duration time.Duration
+	if muted {
+		err, forced = nil, false
+	} else if !forced {
+		start := time.Now()
+		err = registry.runCheck(ctx, name, rc, rc.timeoutOr(registry.defaultTimeout))
+		duration = time.Since(start)
+		if s, ok := rc.checker.(staleGuarded); ok && err == nil {
+			err = s.stale(registry.stalenessMultiplier)
+		}
+	}
+	r := result{
+		muted:         muted,
+		duration:      duration,
+		err:           err,
+		informational: rc.informational,
+		owner:         rc.owner,
+		weight:        rc.weight,
+		forced:        forced,
+	}
+	r.failedOpen = rc.timeoutPolicy == FailOpen && errors.Is(r.err, ErrTimeout)
+	if p, ok := rc.checker.(pausable); ok {
+		r.paused = p.isPaused()
+	}
+	r.invocations, r.lastTrigger = rc.invocationStats()
+	if c, ok := rc.checker.(cachedResult); ok && !forced {
+		r.timestamp = c.resultTime()
+		if !r.timestamp.IsZero() {
+			r.age = time.Since(r.timestamp)
+		}
+	} else {
+		r.timestamp = time.Now()
+	}
+	rc.setLastResult(r)
+
+	return r
+}
+
+// runCheck evaluates the check as part of the evaluation carried by ctx,
+// giving up on it once timeout has elapsed if timeout is positive.
+func (registry *Registry) runCheck(ctx context.Context, name string, rc *registeredCheck, timeout time.Duration) error {
+	if _, ok := rc.checker.(runNotifier); !ok {
+		rc.recordInvocation(triggerOf(ctx))
+	}
+
+	check := registry.wrap(name, rc.checker)
+	if timeout <= 0 {
+		defer registry.track(name)()
+		return rc.call(ctx, check)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// buffered so an abandoned check does not leak its goroutine forever
+	errc := make(chan error, 1)
+	go func() {
+		defer registry.track(name)()
+		errc <- rc.call(ctx, check)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("%w after %v", ErrTimeout, timeout)
+	}
+}
+
+// track records the start of an evaluation of the named check and returns a
+// function recording its end.
+func (registry *Registry) track(name string) func() {
+	e := &evaluation{name: name, start: time.Now()}
+
+	registry.inflightMu.Lock()
+	registry.inflight[e] = struct{}{}
+	registry.inflightMu.Unlock()
+
+	return func() {
+		registry.inflightMu.Lock()
+		delete(registry.inflight, e)
+		registry.inflightMu.Unlock()
+	}
+}
+
+// DeadlockSuspectChecker returns a Checker that fails while any check of the
+// registry has been running for longer than maxCheckDuration, naming the
+// stuck checks. Checks abandoned because of a timeout are still monitored
+// until they return, so this catches checks that ignore their timeout.
+func (registry *Registry) DeadlockSuspectChecker(maxCheckDuration time.Duration) Checker {
+	return CheckFunc(func() error {
+		registry.inflightMu.Lock()
+		defer registry.inflightMu.Unlock()
+
+		now := time.Now()
+		var stuck []string
+		for e := range registry.inflight {
+			if d := now.Sub(e.start); d > maxCheckDuration {
+				stuck = append(stuck, fmt.Sprintf("%s (running for %v)", e.name, d.Round(time.Millisecond)))
+			}
+		}
+		if len(stuck) == 0 {
+			return nil
+		}
+
+		sort.Strings(stuck)
+		return errors.New("checks running longer than " + maxCheckDuration.String() + ": " + strings.Join(stuck, ", "))
+	})
+}
+
+// DeadlockSuspectChecker returns a Checker that fails while any check of the
+// default registry has been running for longer than maxCheckDuration.
+func DeadlockSuspectChecker(maxCheckDuration time.Duration) Checker {
+	return DefaultRegistry.DeadlockSuspectChecker(maxCheckDuration)
+}
+
+// CheckStatus runs every check of the default registry and returns their
+// results.
+func CheckStatus() map[string]CheckResult {
 	return DefaultRegistry.CheckStatus()
 }
 
-// Register associates the checker with the provided name.
-func (registry *Registry) Register(name string, check Checker) {
+// Count returns the number of checks registered with the registry.
+func (registry *Registry) Count() int {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return len(registry.registeredChecks)
+}
+
+// Count returns the number of checks registered with the default registry.
+func Count() int {
+	return DefaultRegistry.Count()
+}
+
+// Empty reports whether no check is registered with the registry, such as to
+// warn about a service starting without health checks.
+func (registry *Registry) Empty() bool {
+	return registry.Count() == 0
+}
+
+// Empty reports whether no check is registered with the default registry.
+func Empty() bool {
+	return DefaultRegistry.Empty()
+}
+
+// CheckedKeys returns the sorted names of the checks registered with the
+// registry, without running them.
+func (registry *Registry) CheckedKeys() []string {
+	registry.mu.RLock()
+	keys := make([]string, 0, len(registry.registeredChecks))
+	for name := range registry.registeredChecks {
+		keys = append(keys, name)
+	}
+	registry.mu.RUnlock()
+
+	sort.Strings(keys)
+	return keys
+}
+
+// CheckedKeys returns the sorted names of the checks registered with the
+// default registry.
+func CheckedKeys() []string {
+	return DefaultRegistry.CheckedKeys()
+}
+
+// ExpectCount returns an error unless exactly n checks are registered with the
+// registry. Calling it once the application is wired guards against checks
+// silently missing because of registration bugs.
+func (registry *Registry) ExpectCount(n int) error {
+	if count := registry.Count(); count != n {
+		return fmt.Errorf("expected %d registered checks, found %d", n, count)
+	}
+	return nil
+}
+
+// ExpectCount returns an error unless exactly n checks are registered with the
+// default registry.
+func ExpectCount(n int) error {
+	return DefaultRegistry.ExpectCount(n)
+}
+
+// Register associates the checker with the provided name. It panics if a
+// check is already registered with name; see ReplaceRegister to replace it.
+func (registry *Registry) Register(name string, check Checker, opts ...CheckOption) {
 	if registry == nil {
 		registry = DefaultRegistry
 	}
+	registry.register(name, check, false, opts)
+}
+
+// ReplaceRegister associates the checker with the provided name, replacing
+// the check already registered with name, if any. A replaced StoppableChecker,
+// such as a periodic check, is stopped.
+func (registry *Registry) ReplaceRegister(name string, check Checker, opts ...CheckOption) {
+	if replaced := registry.register(name, check, true, opts); replaced != nil {
+		if s, ok := replaced.checker.(StoppableChecker); ok {
+			s.Stop()
+		}
+	}
+}
+
+// ReplaceRegister associates the checker with the provided name in the
+// default registry, replacing the check already registered with name, if any.
+func ReplaceRegister(name string, check Checker, opts ...CheckOption) {
+	DefaultRegistry.ReplaceRegister(name, check, opts...)
+}
+
+// register associates the checker with name, returning the check it replaced
+// if replace is set, and panicking if a check is already registered with name
+// otherwise.
+func (registry *Registry) register(name string, check Checker, replace bool, opts []CheckOption) *registeredCheck {
+	rc := &registeredCheck{checker: check, weight: 1}
+	for _, opt := range opts {
+		opt(rc)
+	}
 	registry.mu.Lock()
 	defer registry.mu.Unlock()
-	_, ok := registry.registeredChecks[name]
-	if ok {
+	replaced, ok := registry.registeredChecks[name]
+	if ok && !replace || !ok && registry.lookupLocked(name) != nil {
 		panic("Check already exists: " + name)
 	}
-	registry.registeredChecks[name] = check
+	if name == drainingCheck {
+		panic("Check name is reserved: " + name)
+	}
+	if cycle := registry.dependencyCycle(name, rc); cycle != nil {
+		panic("Check dependency cycle: " + strings.Join(cycle, " -> "))
+	}
+	if replaced != nil {
+		replaced.detached.Store(true)
+	}
+	registry.registeredChecks[name] = rc
+	if p, ok := check.(pausable); ok && registry.paused {
+		p.setPaused(true)
+	}
+
+	// the listeners outlive the registration of the checker, which they
+	// ignore once unregistered or replaced
+	if rn, ok := check.(runNotifier); ok {
+		rn.notifyRuns(func() {
+			if !rc.detached.Load() {
+				rc.recordInvocation(TriggerPeriodic)
+			}
+		})
+	}
+
+	if tn, ok := check.(transitionNotifier); ok {
+		registry.eventsMu.Lock()
+		registry.stateSince[name] = time.Now()
+		registry.eventsMu.Unlock()
+
+		tn.notifyTransitions(func(status error) {
+			if rc.detached.Load() {
+				return
+			}
+			registry.recordEvent(StateChange{
+				Name:    name,
+				Owner:   rc.owner,
+				Healthy: status == nil,
+				Err:     status,
+				Time:    time.Now(),
+			})
+		})
+	}
+
+	return replaced
 }
 
 // Register associates the checker with the provided name in the default
 // registry.
-func Register(name string, check Checker) {
-	DefaultRegistry.Register(name, check)
+func Register(name string, check Checker, opts ...CheckOption) {
+	DefaultRegistry.Register(name, check, opts...)
+}
+
+// Unregister removes the named check from the registry and reports whether it
+// was registered. A StoppableChecker, such as a periodic check, is stopped.
+func (registry *Registry) Unregister(name string) bool {
+	return registry.unregister(name, nil)
+}
+
+// unregister removes the named check from the registry, provided it is
+// checker unless checker is nil, and reports whether it was removed.
+func (registry *Registry) unregister(name string, checker Checker) bool {
+	registry.mu.Lock()
+	rc, ok := registry.registeredChecks[name]
+	if ok && checker != nil && rc.checker != checker {
+		ok = false
+	}
+	if ok {
+		delete(registry.registeredChecks, name)
+		rc.detached.Store(true)
+	}
+	registry.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	registry.eventsMu.Lock()
+	delete(registry.stateSince, name)
+	delete(registry.transitions, name)
+	registry.eventsMu.Unlock()
+
+	if s, ok := rc.checker.(StoppableChecker); ok {
+		s.Stop()
+	}
+	return true
+}
+
+// Unregister removes the named check from the default registry and reports
+// whether it was registered.
+func Unregister(name string) bool {
+	return DefaultRegistry.Unregister(name)
+}
+
+// Clear removes every check from the registry, stopping those implementing
+// StoppableChecker such as periodic checks, and forgets their recent events.
+// The settings and callbacks of the registry, such as its logger and
+// OnStateChange callbacks, are kept. Unlike replacing DefaultRegistry, this is
+// safe to call while the registry is in use, e.g. to isolate tests.
+func (registry *Registry) Clear() {
+	registry.mu.Lock()
+	checks := registry.registeredChecks
+	registry.registeredChecks = make(map[string]*registeredCheck)
+	for _, rc := range checks {
+		rc.detached.Store(true)
+	}
+	registry.mu.Unlock()
+
+	registry.eventsMu.Lock()
+	registry.events = nil
+	registry.stateSince = make(map[string]time.Time)
+	registry.transitions = make(map[string]uint64)
+	registry.eventsMu.Unlock()
+
+	for _, rc := range checks {
+		if s, ok := rc.checker.(StoppableChecker); ok {
+			s.Stop()
+		}
+	}
+}
+
+// Clear removes every check from the default registry.
+func Clear() {
+	DefaultRegistry.Clear()
+}
+
+// RegisterInformational associates the checker with the provided name as an
+// informational check: it is evaluated and part of the Report, but never
+// affects the overall health or the responses of the handlers.
+func (registry *Registry) RegisterInformational(name string, check Checker, opts ...CheckOption) {
+	registry.Register(name, check, append(opts, func(rc *registeredCheck) {
+		rc.informational = true
+	})...)
+}
+
+// RegisterInformational associates the checker with the provided name as an
+// informational check in the default registry.
+func RegisterInformational(name string, check Checker, opts ...CheckOption) {
+	DefaultRegistry.RegisterInformational(name, check, opts...)
+}
+
+// RegisterInGroup associates the checker with the provided name as part of
+// the named group. It is a shorthand for Register with WithGroup.
+func (registry *Registry) RegisterInGroup(group, name string, check Checker, opts ...CheckOption) {
+	registry.Register(name, check, append(opts, WithGroup(group))...)
+}
+
+// RegisterInGroup associates the checker with the provided name as part of
+// the named group in the default registry.
+func RegisterInGroup(group, name string, check Checker, opts ...CheckOption) {
+	DefaultRegistry.RegisterInGroup(group, name, check, opts...)
+}
+
+// hasGroup reports whether any check of the registry, or of the registries it
+// includes, belongs to group.
+func (registry *Registry) hasGroup(group string) bool {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	for _, rc := range registry.registeredChecks {
+		if rc.inGroup(group) {
+			return true
+		}
+	}
+	for _, child := range registry.included {
+		if child.registry.hasGroup(group) {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterReadiness associates the checker with the provided name as a
+// readiness check: it is left out of liveness probes, such as
+// LivenessHandler, so that its failures take the application out of rotation
+// without getting it restarted. This suits dependencies that are slow to warm
+// up. Checks registered otherwise are part of both liveness and readiness
+// probes.
+func (registry *Registry) RegisterReadiness(name string, check Checker, opts ...CheckOption) {
+	registry.Register(name, check, append(opts, func(rc *registeredCheck) {
+		rc.readiness = true
+	})...)
+}
+
+// RegisterReadiness associates the checker with the provided name as a
+// readiness check in the default registry.
+func RegisterReadiness(name string, check Checker, opts ...CheckOption) {
+	DefaultRegistry.RegisterReadiness(name, check, opts...)
 }
 
 // RegisterFunc allows the convenience of registering a checker directly from
 // an arbitrary func() error.
-func (registry *Registry) RegisterFunc(name string, check func() error) {
-	registry.Register(name, CheckFunc(check))
+func (registry *Registry) RegisterFunc(name string, check func() error, opts ...CheckOption) {
+	registry.Register(name, CheckFunc(check), opts...)
 }
 
 // RegisterFunc allows the convenience of registering a checker in the default
 // registry directly from an arbitrary func() error.
-func RegisterFunc(name string, check func() error) {
-	DefaultRegistry.RegisterFunc(name, check)
+func RegisterFunc(name string, check func() error, opts ...CheckOption) {
+	DefaultRegistry.RegisterFunc(name, check, opts...)
+}
+
+// RegisterFuncContext allows the convenience of registering a checker directly
+// from an arbitrary func(context.Context) error, which is passed the deadline
+// of the check.
+func (registry *Registry) RegisterFuncContext(name string, check func(ctx context.Context) error, opts ...CheckOption) {
+	registry.Register(name, CheckFuncContext(check), opts...)
+}
+
+// RegisterFuncContext allows the convenience of registering a checker in the
+// default registry directly from an arbitrary func(context.Context) error.
+func RegisterFuncContext(name string, check func(ctx context.Context) error, opts ...CheckOption) {
+	DefaultRegistry.RegisterFuncContext(name, check, opts...)
 }
 
 // RegisterPeriodicFunc allows the convenience of registering a PeriodicChecker
@@ -224,6 +1350,27 @@ func RegisterPeriodicFunc(name string, period time.Duration, check CheckFunc) {
 	DefaultRegistry.RegisterPeriodicFunc(name, period, check)
 }
 
+// RegisterPeriodic registers check with the provided name as a
+// PeriodicChecker running every period, and returns a function stopping its
+// goroutine and unregistering it, unless another check has replaced it since,
+// for services creating and destroying checks over their lifetime. Calling
+// Unregister with name stops the goroutine too.
+func (registry *Registry) RegisterPeriodic(name string, check CheckFunc, period time.Duration, opts ...CheckOption) (stop func()) {
+	checker := PeriodicChecker(check, period)
+	registry.Register(name, checker, opts...)
+	return func() {
+		registry.unregister(name, checker)
+		checker.Stop()
+	}
+}
+
+// RegisterPeriodic registers check with the provided name as a
+// PeriodicChecker in the default registry, and returns a function stopping
+// and unregistering it.
+func RegisterPeriodic(name string, check CheckFunc, period time.Duration, opts ...CheckOption) (stop func()) {
+	return DefaultRegistry.RegisterPeriodic(name, check, period, opts...)
+}
+
 // RegisterPeriodicThresholdFunc allows the convenience of registering a
 // PeriodicChecker from an arbitrary func() error.
 func (registry *Registry) RegisterPeriodicThresholdFunc(name string, period time.Duration, threshold int, check CheckFunc) {
@@ -236,35 +1383,291 @@ func RegisterPeriodicThresholdFunc(name string, period time.Duration, threshold
 	DefaultRegistry.RegisterPeriodicThresholdFunc(name, period, threshold, check)
 }
 
+// StatusOption configures the handler returned by NewStatusHandler.
+type StatusOption func(*statusConfig)
+
+// statusConfig holds the settings applied by StatusOptions.
+type statusConfig struct {
+	healthyStatus   int
+	unhealthyStatus int
+	liveness        bool
+	groupsPrefix    string                   // path under which groups are served, if any
+	authorized      func(*http.Request) bool // callers allowed to see the details, all if nil
+	limiter         *rateLimiter             // see WithRateLimit
+}
+
+// WithHealthyStatus sets the status code returned when all checks pass,
+// 200 by default. It panics if code is not a 2xx status code.
+func WithHealthyStatus(code int) StatusOption {
+	if code < 200 || code > 299 {
+		panic("healthy status must be a 2xx status code: " + strconv.Itoa(code))
+	}
+	return func(c *statusConfig) {
+		c.healthyStatus = code
+	}
+}
+
+// WithUnhealthyStatus sets the status code returned when any check fails
+// critically, 503 by default. Passing http.StatusOK makes the handler always
+// succeed, for orchestrators that inspect the response body rather than the
+// status code.
+func WithUnhealthyStatus(code int) StatusOption {
+	return func(c *statusConfig) {
+		c.unhealthyStatus = code
+	}
+}
+
+// WithLiveness makes the handler a liveness probe, leaving out the checks
+// registered with RegisterReadiness.
+func WithLiveness() StatusOption {
+	return func(c *statusConfig) {
+		c.liveness = true
+	}
+}
+
+// WithAuthorizedDetails only shows the failing checks and their errors to the
+// requests for which authorized returns true, such as those carrying valid
+// credentials. Other requests, such as those of load balancers, only get the
+// status code, so that dependency names and errors are not exposed publicly.
+func WithAuthorizedDetails(authorized func(*http.Request) bool) StatusOption {
+	return func(c *statusConfig) {
+		c.authorized = authorized
+	}
+}
+
+// NewStatusHandler returns a handler behaving like StatusHandler, reporting
+// the checks of registry with the status codes configured by opts. Unlike
+// StatusHandler, which init registers at /debug/health, it can be mounted at
+// any path:
+//
+//	mux.Handle("/healthz", health.NewStatusHandler(health.DefaultRegistry,
+//		health.WithUnhealthyStatus(http.StatusOK)))
+func NewStatusHandler(registry *Registry, opts ...StatusOption) http.Handler {
+	config := statusConfig{
+		healthyStatus:   http.StatusOK,
+		unhealthyStatus: http.StatusServiceUnavailable,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveStatus(w, r, registry, config)
+	})
+}
+
 // StatusHandler returns a JSON blob with all the currently registered Health Checks
 // and their corresponding status.
 // Returns 503 if any Error status exists, 200 otherwise
+// HEAD requests get the same status code without a body, and requests with
+// other methods than GET and HEAD get a 405.
+//
+// Checks failing with a StatusError of SeverityWarning only degrade the
+// service: they are listed in the response, but do not turn it into a 503.
+// Neither do critical failures while the health score of the registry stays
+// above its threshold, if one is set with SetScoreThreshold.
+// Requests accepting application/json get a JSON object with the overall
+// status, "healthy", "degraded" or "unhealthy", the errors of the failing
+// checks and, apart, those of the checks failing with warnings, see Warnf, and
+// the names of the checks muted with SetEnabled and of the periodic checks
+// paused with PauseAll:
+//
+//	{"status": "unhealthy", "checks": {"<check name>": "<error message>"},
+//		"warnings": {"<check name>": "<error message>"}, "muted": ["<check name>"],
+//		"paused": ["<check name>"]}
+//
+// Requests to /debug/health/<group> only report the checks of the group, see
+// WithGroup, "all" reporting every check. Unknown groups are not found.
+//
+// While the registry is draining, see BeginDrain, the response reports
+// ErrDraining under the "draining" name.
+//
+// The response bodies can be customized with SetResponseRenderer.
 func StatusHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method == "GET" {
-		checks := CheckStatus()
-		status := http.StatusOK
+	serveStatus(w, r, DefaultRegistry, statusConfig{
+		healthyStatus:   http.StatusOK,
+		unhealthyStatus: http.StatusServiceUnavailable,
+		groupsPrefix:    "/debug/health",
+	})
+}
+
+// LivenessHandler is a liveness probe for the checks of the default registry:
+// it behaves like StatusHandler, leaving out the checks registered with
+// RegisterReadiness. A failing liveness probe typically gets the application
+// restarted.
+func LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	serveStatus(w, r, DefaultRegistry, statusConfig{
+		healthyStatus:   http.StatusOK,
+		unhealthyStatus: http.StatusServiceUnavailable,
+		liveness:        true,
+	})
+}
 
-		// If there is an error, return 503
-		if len(checks) != 0 {
-			status = http.StatusServiceUnavailable
+// ReadinessHandler is a readiness probe for the checks of the default
+// registry: it behaves like StatusHandler, evaluating every check including
+// those registered with RegisterReadiness. A failing readiness probe typically
+// takes the application out of rotation, as does draining, see BeginDrain.
+func ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	serveStatus(w, r, DefaultRegistry, statusConfig{
+		healthyStatus:   http.StatusOK,
+		unhealthyStatus: http.StatusServiceUnavailable,
+	})
+}
+
+// serveStatus reports the checks of registry with the status codes of config.
+// HEAD requests only get the status code.
+func serveStatus(w http.ResponseWriter, r *http.Request, registry *Registry, config statusConfig) {
+	if r.Method == "GET" || r.Method == "HEAD" {
+		group := "all"
+		if config.groupsPrefix != "" && strings.HasPrefix(r.URL.Path, config.groupsPrefix+"/") {
+			if g := strings.Trim(strings.TrimPrefix(r.URL.Path, config.groupsPrefix), "/"); g != "" {
+				group = g
+			}
+		}
+		if group != "all" && !registry.hasGroup(group) {
+			http.NotFound(w, r)
+			return
+		}
+
+		include := func(rc *registeredCheck) bool {
+			if config.liveness && rc.readiness {
+				return false
+			}
+			return group == "all" || rc.inGroup(group)
+		}
+		evaluate := func() map[string]result {
+			return registry.evaluateIf(context.Background(), include)
+		}
+		var results map[string]result
+		if config.limiter != nil {
+			results = config.limiter.evaluate(group, evaluate)
+		} else {
+			results = evaluate()
+		}
+		if !config.liveness {
+			registry.drain(results)
+		}
+		checks := registry.failures(results)
+		status := config.healthyStatus
+
+		// If there is a critical error, return the unhealthy status
+		critical := registry.unhealthy(results)
+		if critical {
+			status = config.unhealthyStatus
 		}
 
+		if r.Method == "HEAD" || (config.authorized != nil && !config.authorized(r)) {
+			w.WriteHeader(status)
+			return
+		}
+		if renderer := registry.responseRenderer(); renderer != nil && status != http.StatusNoContent {
+			render(w, status, results, renderer)
+			return
+		}
+		if acceptsJSON(r) {
+			statusResponse(w, r, status, newStatusBody(checks, results, critical))
+			return
+		}
 		statusResponse(w, r, status, checks)
 	} else {
-		http.NotFound(w, r)
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandlerOption configures the handler returned by Handler.
+type HandlerOption func(*handlerConfig)
+
+// handlerConfig holds the settings applied by HandlerOptions.
+type handlerConfig struct {
+	unhealthyFor       time.Duration
+	failedChecksHeader bool
+	failureDetail      bool
+}
+
+// WithUnhealthyFor makes the handler reject requests only once the health
+// checks have been failing continuously for at least d, so that brief
+// transient failures do not take the application out of rotation. The status
+// endpoint still reports the instantaneous state of the checks.
+func WithUnhealthyFor(d time.Duration) HandlerOption {
+	return func(c *handlerConfig) {
+		c.unhealthyFor = d
+	}
+}
+
+// WithFailedChecksHeader makes the handler list the names of the failing
+// checks, sorted and comma-separated, in the X-Health-Failed header of its 503
+// responses, so that the cause shows up in access logs. It is opt-in as it
+// reveals check names to clients.
+func WithFailedChecksHeader() HandlerOption {
+	return func(c *handlerConfig) {
+		c.failedChecksHeader = true
+	}
+}
+
+// WithFailureDetail makes the handler name the headline failure of the
+// registry, see SetHeadlinePolicy, with its error in the body of its 503
+// responses. It is opt-in as it reveals check names and errors to clients,
+// which otherwise only get a generic message.
+func WithFailureDetail() HandlerOption {
+	return func(c *handlerConfig) {
+		c.failureDetail = true
 	}
 }
 
 // Handler returns a handler that will return 503 response code if the health
 // checks have failed. If everything is okay with the health checks, the
 // handler will pass through to the provided handler. Use this handler to
-// disable a web application when the health checks fail.
-func Handler(handler http.Handler) http.Handler {
+// disable a web application when the health checks fail. Failures of
+// SeverityWarning do not disable the application, nor does draining, see
+// BeginDrain, so that requests keep being served while load balancers take
+// the application out of rotation.
+func Handler(handler http.Handler, opts ...HandlerOption) http.Handler {
+	var config handlerConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	var (
+		mu             sync.Mutex
+		unhealthySince time.Time
+	)
+	// failingFor records the outcome of the latest evaluation and returns
+	// how long the checks have been failing without interruption.
+	failingFor := func(healthy bool) time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if healthy {
+			unhealthySince = time.Time{}
+			return 0
+		}
+		now := time.Now()
+		if unhealthySince.IsZero() {
+			unhealthySince = now
+		}
+		return now.Sub(unhealthySince)
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		checks := CheckStatus()
-		if len(checks) != 0 {
-			errcode.ServeJSON(w, errcode.ErrorCodeUnavailable.
-				WithDetail("health check failed: please see /debug/health"))
+		results := DefaultRegistry.evaluate(context.Background())
+		checks := DefaultRegistry.failures(results)
+		critical := DefaultRegistry.unhealthy(results)
+		failing := failingFor(!critical)
+		if critical && failing >= config.unhealthyFor {
+			if config.failedChecksHeader {
+				names := make([]string, 0, len(checks))
+				for name := range checks {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				w.Header().Set("X-Health-Failed", strings.Join(names, ","))
+			}
+			detail := "health check failed: please see /debug/health"
+			if config.failureDetail {
+				detail = "health check failed: " + DefaultRegistry.headline(results) + "; please see /debug/health"
+			}
+			errcode.ServeJSON(w, errcode.ErrorCodeUnavailable.WithDetail(detail))
 			return
 		}
 
@@ -272,12 +1675,81 @@ func Handler(handler http.Handler) http.Handler {
 	})
 }
 
+// statusBody is the response body of the status handlers to requests
+// accepting JSON.
+type statusBody struct {
+	Status   string            `json:"status"`             // StatusHealthy, StatusDegraded or StatusUnhealthy
+	Checks   map[string]string `json:"checks"`             // errors of the failing checks
+	Warnings map[string]string `json:"warnings,omitempty"` // errors of the checks failing with SeverityWarning
+	Muted    []string          `json:"muted,omitempty"`    // sorted names of the checks muted with SetEnabled
+	Paused   []string          `json:"paused,omitempty"`   // sorted names of the periodic checks paused with PauseAll
+
+	// sorted names of the checks not yet evaluated, see PublishExpvar
+	NotEvaluated []string `json:"not_evaluated,omitempty"`
+}
+
+// newStatusBody returns the status body reporting the failing checks, keyed
+// by name with their results, any of which failed critically if critical is
+// set. Warnings are reported apart from the other failures.
+func newStatusBody(checks map[string]string, results map[string]result, critical bool) statusBody {
+	body := statusBody{Status: StatusHealthy, Checks: make(map[string]string)}
+	for name, message := range checks {
+		if SeverityOf(results[name].err) == SeverityWarning {
+			if body.Warnings == nil {
+				body.Warnings = make(map[string]string)
+			}
+			body.Warnings[name] = message
+		} else {
+			body.Checks[name] = message
+		}
+	}
+	for name, r := range results {
+		if r.muted {
+			body.Muted = append(body.Muted, name)
+		}
+		if r.paused {
+			body.Paused = append(body.Paused, name)
+		}
+		if r.unevaluated {
+			body.NotEvaluated = append(body.NotEvaluated, name)
+		}
+	}
+	sort.Strings(body.Muted)
+	sort.Strings(body.Paused)
+	sort.Strings(body.NotEvaluated)
+	if critical {
+		body.Status = StatusUnhealthy
+	} else if len(checks) != 0 {
+		body.Status = StatusDegraded
+	}
+	return body
+}
+
+// acceptsJSON reports whether the request explicitly accepts JSON responses.
+func acceptsJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, _, _ := strings.Cut(mediaRange, ";")
+			if strings.TrimSpace(mediaType) == "application/json" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // statusResponse completes the request with a response describing the health
-// of the service.
-func statusResponse(w http.ResponseWriter, r *http.Request, status int, checks map[string]string) {
-	p, err := json.Marshal(checks)
+// of the service, body being serialized to JSON.
+func statusResponse(w http.ResponseWriter, r *http.Request, status int, body interface{}) {
+	if status == http.StatusNoContent {
+		// a 204 response must not carry a body
+		w.WriteHeader(status)
+		return
+	}
+
+	p, err := json.Marshal(body)
 	if err != nil {
-		context.GetLogger(context.Background()).Errorf("error serializing health status: %v", err)
+		dcontext.GetLogger(dcontext.Background()).Errorf("error serializing health status: %v", err)
 		p, err = json.Marshal(struct {
 			ServerError string `json:"server_error"`
 		}{
@@ -286,7 +1758,7 @@ func statusResponse(w http.ResponseWriter, r *http.Request, status int, checks m
 		status = http.StatusInternalServerError
 
 		if err != nil {
-			context.GetLogger(context.Background()).Errorf("error serializing health status failure message: %v", err)
+			dcontext.GetLogger(dcontext.Background()).Errorf("error serializing health status failure message: %v", err)
 			return
 		}
 	}
@@ -295,7 +1767,7 @@ func statusResponse(w http.ResponseWriter, r *http.Request, status int, checks m
 	w.Header().Set("Content-Length", fmt.Sprint(len(p)))
 	w.WriteHeader(status)
 	if _, err := w.Write(p); err != nil {
-		context.GetLogger(context.Background()).Errorf("error writing health status response body: %v", err)
+		dcontext.GetLogger(dcontext.Background()).Errorf("error writing health status response body: %v", err)
 	}
 }
 
@@ -303,4 +1775,6 @@ func statusResponse(w http.ResponseWriter, r *http.Request, status int, checks m
 func init() {
 	DefaultRegistry = NewRegistry()
 	http.HandleFunc("/debug/health", StatusHandler)
+	http.HandleFunc("/debug/health/", StatusHandler)
+	http.HandleFunc("/debug/health/summary", SummaryHandler)
 }
@@ -1,11 +1,15 @@
 package health
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // TestReturns200IfThereAreNoChecks ensures that the result code of the health
@@ -47,6 +51,32 @@ func TestReturns503IfThereAreErrorChecks(t *testing.T) {
 	}
 }
 
+// TestStatusHandlerMethods ensures that HEAD requests get the status code
+// without a body, and that methods other than GET and HEAD are not allowed.
+func TestStatusHandlerMethods(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("db", func() error { return errors.New("down") })
+	handler := NewStatusHandler(registry)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("HEAD", "/debug/health", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Did not get a 503, got %d", recorder.Code)
+	}
+	if recorder.Body.Len() != 0 {
+		t.Errorf("unexpected body: %q", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/debug/health", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Did not get a 405, got %d", recorder.Code)
+	}
+	if allow := recorder.Header().Get("Allow"); allow != "GET, HEAD" {
+		t.Errorf("unexpected Allow header: %q", allow)
+	}
+}
+
 // TestHealthHandler ensures that our handler implementation correct protects
 // the web application when things aren't so healthy.
 func TestHealthHandler(t *testing.T) {
@@ -105,3 +135,874 @@ func TestHealthHandler(t *testing.T) {
 	updater.Update(nil)
 	checkUp(t, "when server is back up") // now we should be back up.
 }
+
+// TestDefaultTimeout ensures that the default timeout of a registry fails
+// hung checks, and that a per-check timeout overrides it.
+func TestDefaultTimeout(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetDefaultTimeout(10 * time.Millisecond)
+
+	block := make(chan struct{})
+	defer close(block)
+	registry.RegisterFunc("hung", func() error {
+		<-block
+		return nil
+	})
+	registry.RegisterFunc("slow", func() error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}, WithTimeout(time.Second))
+
+	var deadline time.Time
+	registry.RegisterFuncContext("context", func(ctx context.Context) error {
+		deadline, _ = ctx.Deadline()
+		return nil
+	})
+
+	status := registry.failingChecks()
+	if _, ok := status["hung"]; !ok {
+		t.Errorf("hung check was expected to time out")
+	}
+	if err, ok := status["slow"]; ok {
+		t.Errorf("slow check was expected to use its own timeout, error:%v", err)
+	}
+	if deadline.IsZero() {
+		t.Errorf("context-aware check was expected to receive a deadline")
+	}
+}
+
+// TestHealthHandlerUnhealthyFor ensures that a handler created with
+// WithUnhealthyFor only rejects requests once the checks have been failing
+// for the configured duration.
+func TestHealthHandlerUnhealthyFor(t *testing.T) {
+	// clear out existing checks.
+	DefaultRegistry = NewRegistry()
+
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), WithUnhealthyFor(50*time.Millisecond))
+
+	updater := NewStatusUpdater()
+	Register("test_check", updater)
+
+	expect := func(t *testing.T, message string, code int) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		if recorder.Code != code {
+			t.Fatalf("unexpected response code when %s: %d != %d", message, recorder.Code, code)
+		}
+	}
+
+	updater.Update(errors.New("blip"))
+	expect(t, "failure has just started", http.StatusNoContent)
+
+	updater.Update(nil)
+	expect(t, "failure has recovered", http.StatusNoContent)
+
+	updater.Update(errors.New("outage"))
+	expect(t, "failure has started again", http.StatusNoContent)
+	time.Sleep(60 * time.Millisecond)
+	expect(t, "failure is sustained", http.StatusServiceUnavailable)
+}
+
+// TestDeadlockSuspectChecker ensures that a check ignoring its timeout is
+// reported as stuck until it returns.
+func TestDeadlockSuspectChecker(t *testing.T) {
+	registry := NewRegistry()
+
+	block := make(chan struct{})
+	done := make(chan struct{})
+	registry.RegisterFunc("hung", func() error {
+		defer close(done)
+		<-block
+		return nil
+	}, WithTimeout(time.Millisecond))
+
+	suspect := registry.DeadlockSuspectChecker(10 * time.Millisecond)
+	if err := suspect.Check(); err != nil {
+		t.Fatalf("no check was expected to be running, error:%v", err)
+	}
+
+	registry.failingChecks()
+	time.Sleep(20 * time.Millisecond)
+	if err := suspect.Check(); err == nil {
+		t.Errorf("abandoned check was expected to be reported as stuck")
+	}
+
+	close(block)
+	<-done
+	for i := 0; suspect.Check() != nil; i++ {
+		if i == 100 {
+			t.Fatalf("check was expected to no longer be reported once returned")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestStatusHandlerCustomStatus ensures that the status codes of a status
+// handler can be configured.
+func TestStatusHandlerCustomStatus(t *testing.T) {
+	registry := NewRegistry()
+	handler := NewStatusHandler(registry, WithHealthyStatus(http.StatusNoContent), WithUnhealthyStatus(http.StatusInternalServerError))
+
+	updater := NewStatusUpdater()
+	registry.Register("test_check", updater)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/health", nil))
+	if recorder.Code != http.StatusNoContent {
+		t.Errorf("unexpected healthy status: %d", recorder.Code)
+	}
+
+	updater.Update(errors.New("down"))
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/health", nil))
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected unhealthy status: %d", recorder.Code)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("non-2xx healthy status was expected to panic")
+		}
+	}()
+	WithHealthyStatus(http.StatusServiceUnavailable)
+}
+
+// TestPeriodicCheckerContext ensures that a periodic checker stops running its
+// check once its context is cancelled, keeping the last result.
+func TestPeriodicCheckerContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var mu sync.Mutex
+	calls := 0
+	checker := PeriodicCheckerContext(ctx, CheckFunc(func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return errors.New("down")
+	}), time.Millisecond)
+
+	for checker.Check() == nil {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	time.Sleep(5 * time.Millisecond) // let an in-flight tick complete
+
+	mu.Lock()
+	before := calls
+	mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	after := calls
+	mu.Unlock()
+
+	if after != before {
+		t.Errorf("check was expected to stop running after cancellation: %d calls != %d", after, before)
+	}
+	if checker.Check() == nil {
+		t.Errorf("checker was expected to keep its last result")
+	}
+}
+
+// TestPeriodicCheckerLastRun ensures that periodic checkers report when they
+// last ran, which stops advancing once they are stopped.
+func TestPeriodicCheckerLastRun(t *testing.T) {
+	checker := PeriodicChecker(CheckFunc(func() error { return nil }), time.Millisecond)
+	lr, ok := checker.(LastRunner)
+	if !ok {
+		t.Fatal("periodic checker was expected to implement LastRunner")
+	}
+	if !lr.LastRun().IsZero() {
+		t.Errorf("unexpected last run before the first: %v", lr.LastRun())
+	}
+
+	for i := 0; lr.LastRun().IsZero(); i++ {
+		if i == 100 {
+			t.Fatal("check was expected to run periodically")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	checker.Stop()
+	time.Sleep(5 * time.Millisecond) // let an in-flight tick complete
+
+	last := lr.LastRun()
+	time.Sleep(20 * time.Millisecond)
+	if !lr.LastRun().Equal(last) {
+		t.Errorf("last run was not expected to advance after Stop")
+	}
+
+	registry := NewRegistry()
+	registry.Register("periodic", checker)
+	if ts := registry.CheckStatus()["periodic"].Timestamp; !ts.Equal(last) {
+		t.Errorf("expected the last run %v as timestamp, got %v", last, ts)
+	}
+}
+
+// TestTimeoutPolicy ensures that the timeouts of fail-open checks are reported
+// without affecting the overall health.
+func TestTimeoutPolicy(t *testing.T) {
+	registry := NewRegistry()
+
+	block := make(chan struct{})
+	defer close(block)
+	hung := func() error {
+		<-block
+		return nil
+	}
+	registry.RegisterFunc("optional", hung, WithTimeout(time.Millisecond), WithTimeoutPolicy(FailOpen))
+
+	report := registry.Report()
+	if report.Status != StatusHealthy {
+		t.Errorf("fail-open timeout was not expected to affect the status: %s", report.Status)
+	}
+	if cr := report.Checks["optional"]; !cr.FailedOpen || cr.Error == "" {
+		t.Errorf("fail-open timeout was expected in the report: %+v", cr)
+	}
+
+	registry.RegisterFunc("critical", hung, WithTimeout(time.Millisecond))
+	if status := registry.failingChecks(); len(status) != 1 || status["critical"] == "" {
+		t.Errorf("only the fail-closed timeout was expected to fail: %v", status)
+	}
+}
+
+// TestHealthHandlerFailedChecksHeader ensures that the failing checks are
+// listed in a header of 503 responses only when requested.
+func TestHealthHandlerFailedChecksHeader(t *testing.T) {
+	// clear out existing checks.
+	DefaultRegistry = NewRegistry()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	RegisterFunc("queue", func() error { return errors.New("down") })
+	RegisterFunc("db", func() error { return errors.New("down") })
+	RegisterFunc("cache", func() error { return nil })
+
+	recorder := httptest.NewRecorder()
+	Handler(next, WithFailedChecksHeader()).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	if recorder.Code != http.StatusServiceUnavailable || recorder.Header().Get("X-Health-Failed") != "db,queue" {
+		t.Errorf("unexpected response: %d %q", recorder.Code, recorder.Header().Get("X-Health-Failed"))
+	}
+
+	recorder = httptest.NewRecorder()
+	Handler(next).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	if header := recorder.Header().Get("X-Health-Failed"); header != "" {
+		t.Errorf("failed checks were not expected in the header by default: %q", header)
+	}
+}
+
+// TestHealthHandlerFailureDetail ensures that the headline failure is only
+// part of the body of 503 responses when requested.
+func TestHealthHandlerFailureDetail(t *testing.T) {
+	// clear out existing checks.
+	DefaultRegistry = NewRegistry()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	RegisterFunc("db", func() error { return errors.New("connection refused") })
+
+	recorder := httptest.NewRecorder()
+	Handler(next).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	if body := recorder.Body.String(); recorder.Code != http.StatusServiceUnavailable || strings.Contains(body, "connection refused") {
+		t.Errorf("failure details were not expected by default: %d %s", recorder.Code, body)
+	}
+
+	recorder = httptest.NewRecorder()
+	Handler(next, WithFailureDetail()).ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	if body := recorder.Body.String(); !strings.Contains(body, "db: connection refused") {
+		t.Errorf("headline failure was expected in the response: %s", body)
+	}
+}
+
+// TestMaxErrorLength ensures that long error messages are truncated in the
+// outputs of the registry.
+func TestMaxErrorLength(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("dump", func() error {
+		return errors.New(strings.Repeat("x", 2000))
+	})
+
+	if msg := registry.failingChecks()["dump"]; msg != strings.Repeat("x", 1024)+"..." {
+		t.Errorf("error message was expected to be truncated to 1KB, got %d bytes", len(msg))
+	}
+
+	registry.SetMaxErrorLength(4)
+	if msg := registry.Report().Checks["dump"].Error; msg != "xxxx..." {
+		t.Errorf("unexpected truncated error message: %q", msg)
+	}
+
+	registry.SetMaxErrorLength(0)
+	if msg := registry.failingChecks()["dump"]; len(msg) != 2000 {
+		t.Errorf("error message was not expected to be truncated, got %d bytes", len(msg))
+	}
+}
+
+// TestExpectCount ensures that the number of registered checks can be
+// asserted.
+func TestExpectCount(t *testing.T) {
+	registry := NewRegistry()
+	if !registry.Empty() {
+		t.Errorf("a new registry was expected to be empty")
+	}
+	registry.RegisterFunc("db", func() error { return nil })
+	registry.RegisterFunc("cache", func() error { return nil })
+
+	if count := registry.Count(); count != 2 {
+		t.Errorf("2 checks were expected, got %d", count)
+	}
+	if registry.Empty() {
+		t.Errorf("a registry with checks was not expected to be empty")
+	}
+	if err := registry.ExpectCount(2); err != nil {
+		t.Errorf("unexpected error:%v", err)
+	}
+	if err := registry.ExpectCount(3); err == nil {
+		t.Errorf("a mismatching count was expected to fail")
+	}
+}
+
+// TestCheckedKeys ensures that the names of the registered checks are listed
+// without running them.
+func TestCheckedKeys(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("queue", func() error { panic("not expected to run") })
+	registry.RegisterFunc("db", func() error { panic("not expected to run") })
+
+	keys := registry.CheckedKeys()
+	if len(keys) != 2 || keys[0] != "db" || keys[1] != "queue" {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+}
+
+// TestUnregister ensures that an unregistered check is no longer evaluated and
+// that its periodic goroutine stops.
+func TestUnregister(t *testing.T) {
+	registry := NewRegistry()
+
+	var mu sync.Mutex
+	runs := 0
+	registry.Register("periodic", PeriodicChecker(CheckFunc(func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		runs++
+		return errors.New("down")
+	}), time.Millisecond))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			NewStatusHandler(registry).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		}
+	}()
+
+	if !registry.Unregister("periodic") {
+		t.Errorf("check was expected to be registered")
+	}
+	if registry.Unregister("periodic") {
+		t.Errorf("check was not expected to be registered anymore")
+	}
+	<-done
+
+	if status := registry.failingChecks(); len(status) != 0 {
+		t.Errorf("unregistered check was not expected to be evaluated: %v", status)
+	}
+
+	mu.Lock()
+	before := runs
+	mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	after := runs
+	mu.Unlock()
+	if after > before+1 {
+		t.Errorf("periodic goroutine was expected to stop: %d runs after unregistering", after-before)
+	}
+}
+
+// TestRegisterPeriodic ensures that the handle returned by RegisterPeriodic
+// stops and unregisters the check, leaving alone a check replacing it.
+func TestRegisterPeriodic(t *testing.T) {
+	registry := NewRegistry()
+
+	var mu sync.Mutex
+	runs := 0
+	stop := registry.RegisterPeriodic("periodic", CheckFunc(func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		runs++
+		return nil
+	}), time.Millisecond)
+
+	stop()
+	if registry.Count() != 0 {
+		t.Errorf("check was expected to be unregistered")
+	}
+	mu.Lock()
+	before := runs
+	mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	after := runs
+	mu.Unlock()
+	if after > before+1 {
+		t.Errorf("periodic goroutine was expected to stop: %d runs after stopping", after-before)
+	}
+
+	stop = registry.RegisterPeriodic("periodic", CheckFunc(func() error { return nil }), time.Millisecond)
+	registry.ReplaceRegister("periodic", CheckFunc(func() error { return errors.New("replacement") }))
+	stop()
+	if status := registry.failingChecks(); status["periodic"] != "replacement" {
+		t.Errorf("replacing check was expected to stay registered: %v", status)
+	}
+}
+
+// TestStatusHandlerJSON ensures that requests accepting JSON get the overall
+// status along with the failing checks.
+func TestStatusHandlerJSON(t *testing.T) {
+	registry := NewRegistry()
+	handler := NewStatusHandler(registry)
+	registry.RegisterFunc("db", func() error { return errors.New("down") })
+
+	req := httptest.NewRequest("GET", "/debug/health", nil)
+	req.Header.Set("Accept", "text/html, application/json;q=0.9")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	if body := recorder.Body.String(); body != `{"status":"unhealthy","checks":{"db":"down"}}` {
+		t.Errorf("unexpected body: %s", body)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/health", nil))
+	if body := recorder.Body.String(); body != `{"db":"down"}` {
+		t.Errorf("unexpected body without content negotiation: %s", body)
+	}
+}
+
+// TestCheckStatus ensures that every result is returned along with when it
+// was produced.
+func TestCheckStatus(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("db", func() error { return nil })
+	registry.RegisterFunc("queue", func() error { return errors.New("down") })
+	periodic := DrivenChecker(CheckFunc(func() error { return nil }))
+	registry.Register("periodic", periodic)
+
+	before := time.Now()
+	status := registry.CheckStatus()
+	if len(status) != 3 {
+		t.Fatalf("every result was expected: %v", status)
+	}
+	if r := status["db"]; r.Name != "db" || r.Err != nil || r.Timestamp.Before(before) {
+		t.Errorf("unexpected passing result: %+v", r)
+	}
+	if r := status["queue"]; r.Err == nil || r.Err.Error() != "down" {
+		t.Errorf("unexpected failing result: %+v", r)
+	}
+	if r := status["periodic"]; !r.Timestamp.IsZero() {
+		t.Errorf("periodic check was not expected to have a result before its first run: %+v", r)
+	}
+
+	registry.Tick()
+	ticked := time.Now()
+	time.Sleep(time.Millisecond)
+	if r := registry.CheckStatus()["periodic"]; r.Timestamp.IsZero() || r.Timestamp.After(ticked) {
+		t.Errorf("periodic check was expected to report when it last ran: %+v", r)
+	}
+}
+
+// TestConcurrentEvaluation ensures that the checks of an evaluation run
+// concurrently and are reported in name order.
+func TestConcurrentEvaluation(t *testing.T) {
+	registry := NewRegistry()
+
+	// every check waits for all of them to have started
+	var started sync.WaitGroup
+	started.Add(3)
+	for _, name := range []string{"c", "a", "b"} {
+		registry.RegisterFunc(name, func() error {
+			started.Done()
+			started.Wait()
+			return errors.New("down")
+		})
+	}
+
+	done := make(chan struct{})
+	recorder := httptest.NewRecorder()
+	go func() {
+		defer close(done)
+		NewStatusHandler(registry).ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/health", nil))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("checks were expected to run concurrently")
+	}
+	if body := recorder.Body.String(); body != `{"a":"down","b":"down","c":"down"}` {
+		t.Errorf("unexpected body: %s", body)
+	}
+}
+
+// TestStatusHandlerHungCheck ensures that a hung check does not block the
+// status endpoint once the default registry has a default timeout.
+func TestStatusHandlerHungCheck(t *testing.T) {
+	// clear out existing checks.
+	DefaultRegistry = NewRegistry()
+	SetDefaultTimeout(10 * time.Millisecond)
+
+	block := make(chan struct{})
+	defer close(block)
+	RegisterFunc("hung", func() error {
+		<-block
+		return nil
+	})
+
+	recorder := httptest.NewRecorder()
+	StatusHandler(recorder, httptest.NewRequest("GET", "/debug/health", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("hung check was expected to fail with a timeout: %d %s", recorder.Code, recorder.Body)
+	}
+}
+
+// TestLivenessAndReadiness ensures that readiness checks are left out of
+// liveness probes only.
+func TestLivenessAndReadiness(t *testing.T) {
+	// clear out existing checks.
+	DefaultRegistry = NewRegistry()
+	RegisterReadiness("warming", CheckFunc(func() error { return errors.New("cache is warming up") }))
+	RegisterFunc("process", func() error { return nil })
+
+	recorder := httptest.NewRecorder()
+	LivenessHandler(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("readiness check was not expected to fail the liveness probe: %d %s", recorder.Code, recorder.Body)
+	}
+
+	recorder = httptest.NewRecorder()
+	ReadinessHandler(recorder, httptest.NewRequest("GET", "/readyz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("readiness check was expected to fail the readiness probe: %d", recorder.Code)
+	}
+
+	registry := NewRegistry()
+	registry.RegisterFunc("process", func() error { return errors.New("down") })
+	recorder = httptest.NewRecorder()
+	NewStatusHandler(registry, WithLiveness()).ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("liveness check was expected to fail the liveness probe: %d", recorder.Code)
+	}
+}
+
+// TestPeriodicCheckerStop ensures that a stopped periodic checker no longer
+// runs its check and keeps its last result.
+func TestPeriodicCheckerStop(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	checker := PeriodicThresholdChecker(CheckFunc(func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return errors.New("down")
+	}), time.Millisecond, 1)
+
+	for checker.Check() == nil {
+		time.Sleep(time.Millisecond)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checker.Stop()
+		}()
+	}
+	wg.Wait()
+	time.Sleep(5 * time.Millisecond) // let an in-flight run complete
+
+	mu.Lock()
+	before := calls
+	mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	after := calls
+	mu.Unlock()
+
+	if after != before {
+		t.Errorf("check was expected to stop running: %d calls != %d", after, before)
+	}
+	if checker.Check() == nil {
+		t.Errorf("checker was expected to keep its last result")
+	}
+}
+
+// TestPeriodicCheckerWithJitter ensures that a jittered periodic checker runs
+// its check right away and then periodically.
+func TestPeriodicCheckerWithJitter(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	checker := PeriodicCheckerWithJitter(CheckFunc(func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return errors.New("down")
+	}), time.Hour, 0.25)
+	defer checker.Stop()
+
+	for i := 0; checker.Check() == nil; i++ {
+		if i == 100 {
+			t.Fatal("check was expected to run right away")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	fast := PeriodicCheckerWithJitter(CheckFunc(func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return nil
+	}), time.Millisecond, 0.5)
+	defer fast.Stop()
+
+	for i := 0; ; i++ {
+		mu.Lock()
+		n := calls
+		mu.Unlock()
+		if n >= 5 {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("check was expected to run periodically, ran %d times", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestPeriodicBackoffChecker ensures that a periodic backoff checker runs its
+// check less often while it fails, and at its base period again once it
+// passes.
+func TestPeriodicBackoffChecker(t *testing.T) {
+	const failures = 6
+	runs := make(chan time.Time, failures+2)
+	calls := 0
+	checker := PeriodicBackoffChecker(CheckFunc(func() error {
+		calls++
+		runs <- time.Now()
+		if calls <= failures {
+			return errors.New("down")
+		}
+		return nil
+	}), time.Millisecond, time.Hour)
+
+	var times []time.Time
+	for len(times) < failures+2 {
+		select {
+		case run := <-runs:
+			times = append(times, run)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("check was expected to keep running, ran %d times", len(times))
+		}
+	}
+	checker.Stop()
+
+	// the interval doubles after every failure, from 2ms after the first
+	for i := 1; i <= failures; i++ {
+		if gap, min := times[i].Sub(times[i-1]), time.Millisecond<<i; gap < min {
+			t.Errorf("run %d happened %v after the previous failure, expected at least %v", i+1, gap, min)
+		}
+	}
+	// without resetting, the interval would be 128ms after the success
+	if gap := times[failures+1].Sub(times[failures]); gap >= 64*time.Millisecond {
+		t.Errorf("run after the success happened %v later, expected the base period", gap)
+	}
+	if err := checker.Check(); err != nil {
+		t.Errorf("unexpected check error:%v", err)
+	}
+}
+
+// TestStatusHandlerSeverity ensures that warnings degrade the service without
+// making the status handlers fail.
+func TestStatusHandlerSeverity(t *testing.T) {
+	registry := NewRegistry()
+	handler := NewStatusHandler(registry)
+	registry.RegisterFunc("replica", func() error {
+		return Warnf("lagging by %v", 5*time.Second)
+	})
+
+	req := httptest.NewRequest("GET", "/debug/health", nil)
+	req.Header.Set("Accept", "application/json")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK || recorder.Body.String() != `{"status":"degraded","checks":{},"warnings":{"replica":"lagging by 5s"}}` {
+		t.Errorf("unexpected degraded response: %d %s", recorder.Code, recorder.Body)
+	}
+
+	registry.RegisterFunc("db", func() error { return errors.New("down") })
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("plain errors were expected to be critical: %d", recorder.Code)
+	}
+	if body := recorder.Body.String(); body != `{"status":"unhealthy","checks":{"db":"down"},"warnings":{"replica":"lagging by 5s"}}` {
+		t.Errorf("unexpected unhealthy response: %s", body)
+	}
+}
+
+// TestStatusHandlerAlwaysOK ensures that a status handler can report failures
+// in its body only.
+func TestStatusHandlerAlwaysOK(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("db", func() error { return errors.New("down") })
+
+	recorder := httptest.NewRecorder()
+	NewStatusHandler(registry, WithUnhealthyStatus(http.StatusOK)).ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != `{"db":"down"}` {
+		t.Errorf("unexpected response: %d %s", recorder.Code, recorder.Body)
+	}
+}
+
+// TestReplaceRegister ensures that a check can be replaced intentionally,
+// while registering a duplicate name otherwise panics.
+func TestReplaceRegister(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("db", func() error { return errors.New("old") })
+	registry.ReplaceRegister("db", CheckFunc(func() error { return errors.New("new") }))
+	registry.ReplaceRegister("cache", CheckFunc(func() error { return nil }))
+
+	if status := registry.failingChecks(); len(status) != 1 || status["db"] != "new" {
+		t.Errorf("the replacing check was expected to be evaluated: %v", status)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("registering a duplicate name was expected to panic")
+		}
+	}()
+	registry.RegisterFunc("db", func() error { return nil })
+}
+
+// TestClear ensures that clearing a registry removes its checks and events but
+// keeps its callbacks.
+func TestClear(t *testing.T) {
+	registry := NewRegistry()
+	changes := 0
+	registry.OnStateChange(func(string, bool, error) { changes++ })
+
+	updater := NewStatusUpdater()
+	registry.Register("db", updater)
+	updater.Update(nil)
+	updater.Update(errors.New("down"))
+	registry.RegisterPeriodicFunc("periodic", time.Hour, func() error { return nil })
+
+	registry.Clear()
+	if count := registry.Count(); count != 0 {
+		t.Errorf("no check was expected after clearing, got %d", count)
+	}
+	if events := registry.RecentEvents(10); len(events) != 0 {
+		t.Errorf("no event was expected after clearing: %+v", events)
+	}
+
+	updater = NewStatusUpdater()
+	registry.Register("db", updater)
+	updater.Update(nil)
+	updater.Update(errors.New("down"))
+	if changes != 2 {
+		t.Errorf("callbacks were expected to be kept, got %d changes", changes)
+	}
+}
+
+// TestStatusHandlerGroups ensures that the status handler reports the checks
+// of the group in the request path.
+func TestStatusHandlerGroups(t *testing.T) {
+	// clear out existing checks.
+	DefaultRegistry = NewRegistry()
+	RegisterInGroup("billing", "ledger", CheckFunc(func() error { return errors.New("down") }))
+	RegisterFunc("search-index", func() error { return nil }, WithGroup("search"))
+
+	for path, code := range map[string]int{
+		"/debug/health":         http.StatusServiceUnavailable,
+		"/debug/health/all":     http.StatusServiceUnavailable,
+		"/debug/health/billing": http.StatusServiceUnavailable,
+		"/debug/health/search/": http.StatusOK,
+		"/debug/health/unknown": http.StatusNotFound,
+	} {
+		recorder := httptest.NewRecorder()
+		StatusHandler(recorder, httptest.NewRequest("GET", path, nil))
+		if recorder.Code != code {
+			t.Errorf("%s: unexpected status %d, expected %d", path, recorder.Code, code)
+		}
+	}
+}
+
+// TestStatusUpdaterLastUpdate ensures that a status updater tells what it was
+// last updated with and when.
+func TestStatusUpdaterLastUpdate(t *testing.T) {
+	updater := NewStatusUpdater()
+	if updater.LastError() != nil || !updater.LastUpdated().IsZero() {
+		t.Fatalf("unexpected last update before the first: %v at %v", updater.LastError(), updater.LastUpdated())
+	}
+
+	before := time.Now()
+	updater.Update(errors.New("down"))
+	if err := updater.LastError(); err == nil || err.Error() != "down" {
+		t.Errorf("unexpected last error: %v", err)
+	}
+	updated := updater.LastUpdated()
+	if updated.Before(before) || updated.After(time.Now()) {
+		t.Errorf("unexpected last update time: %v", updated)
+	}
+
+	updater.Update(nil)
+	if updater.LastError() != nil {
+		t.Errorf("unexpected last error: %v", updater.LastError())
+	}
+	if updater.LastUpdated().Before(updated) {
+		t.Errorf("last update time went backwards: %v", updater.LastUpdated())
+	}
+}
+
+// TestNamedThresholdStatusUpdater ensures that the errors of a named
+// threshold updater are prefixed with its name.
+func TestNamedThresholdStatusUpdater(t *testing.T) {
+	updater := NewNamedThresholdStatusUpdater("replica-3", 2)
+	down := errors.New("connection refused")
+
+	updater.Update(down)
+	if err := updater.Check(); err != nil {
+		t.Errorf("a failure below the threshold was not expected to be reported, error:%v", err)
+	}
+	updater.Update(down)
+	err := updater.Check()
+	if err == nil || err.Error() != "replica-3: connection refused" || !errors.Is(err, down) {
+		t.Errorf("unexpected error:%v", err)
+	}
+
+	updater.Update(nil)
+	if err := updater.Check(); err != nil {
+		t.Errorf("unexpected error:%v", err)
+	}
+}
+
+// TestStatusHandlerAuthorizedDetails ensures that only authorized requests
+// get the details of the failing checks.
+func TestStatusHandlerAuthorizedDetails(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("db", func() error { return errors.New("down") })
+	handler := NewStatusHandler(registry, WithAuthorizedDetails(func(r *http.Request) bool {
+		user, password, ok := r.BasicAuth()
+		return ok && user == "ops" && password == "secret"
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusServiceUnavailable || recorder.Body.Len() != 0 {
+		t.Errorf("unexpected anonymous response: %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	req.SetBasicAuth("ops", "secret")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusServiceUnavailable || recorder.Body.String() != `{"db":"down"}` {
+		t.Errorf("unexpected authorized response: %d %q", recorder.Code, recorder.Body.String())
+	}
+}
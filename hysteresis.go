@@ -0,0 +1,89 @@
+package health
+
+import (
+	"errors"
+	"sync"
+)
+
+// errNotYetChecked is reported by a hysteresis updater until its state is
+// established.
+var errNotYetChecked = errors.New("not yet checked")
+
+// hysteresisUpdater implements Checker and Updater, changing state only after
+// a number of consecutive results in the same direction.
+type hysteresisUpdater struct {
+	mu        sync.Mutex
+	status    error // errNotYetChecked until established
+	decided   bool  // whether a threshold was ever satisfied
+	down, up  int
+	failures  int // consecutive failures, capped at down
+	successes int // consecutive successes, capped at up
+	listeners []func(status error)
+}
+
+// NewHysteresisUpdater returns an Updater that reports unhealthy after
+// downThreshold consecutive failures and healthy again only after upThreshold
+// consecutive successes, avoiding flapping when a dependency is intermittently
+// healthy. While unhealthy, it reports the latest failure. Until either
+// threshold is first satisfied, it reports a "not yet checked" error.
+// Thresholds lower than 1 are treated as 1.
+func NewHysteresisUpdater(downThreshold, upThreshold int) Updater {
+	if downThreshold < 1 {
+		downThreshold = 1
+	}
+	if upThreshold < 1 {
+		upThreshold = 1
+	}
+	return &hysteresisUpdater{status: errNotYetChecked, down: downThreshold, up: upThreshold}
+}
+
+// Check implements the Checker interface.
+func (hu *hysteresisUpdater) Check() error {
+	hu.mu.Lock()
+	defer hu.mu.Unlock()
+
+	return hu.status
+}
+
+// Update implements the Updater interface.
+func (hu *hysteresisUpdater) Update(status error) {
+	hu.mu.Lock()
+	before, decided := hu.status, hu.decided
+	if status == nil {
+		hu.failures = 0
+		if hu.successes < hu.up {
+			hu.successes++
+		}
+	} else {
+		hu.successes = 0
+		if hu.failures < hu.down {
+			hu.failures++
+		}
+	}
+
+	switch {
+	case status == nil && hu.successes >= hu.up:
+		hu.status, hu.decided = nil, true
+	case status != nil && hu.failures >= hu.down:
+		hu.status, hu.decided = status, true
+	case status != nil && hu.decided && hu.status != nil:
+		hu.status = status // still unhealthy, report the latest failure
+	}
+	// establishing the first state is not a transition
+	changed := decided && (before == nil) != (hu.status == nil)
+	after := hu.status
+	listeners := hu.listeners
+	hu.mu.Unlock()
+
+	if changed {
+		notify(listeners, after)
+	}
+}
+
+// notifyTransitions implements the transitionNotifier interface.
+func (hu *hysteresisUpdater) notifyTransitions(listener func(status error)) {
+	hu.mu.Lock()
+	defer hu.mu.Unlock()
+
+	hu.listeners = append(hu.listeners, listener)
+}
@@ -0,0 +1,46 @@
+package health
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestHysteresisUpdater ensures that the updater changes state only after the
+// configured number of consecutive results.
+func TestHysteresisUpdater(t *testing.T) {
+	registry := NewRegistry()
+	updater := NewHysteresisUpdater(2, 3)
+	registry.Register("db", updater)
+
+	down := errors.New("down")
+	steps := []struct {
+		update  error
+		healthy bool
+		checked bool
+	}{
+		{update: nil, healthy: false, checked: false},
+		{update: nil, healthy: false, checked: false},
+		{update: nil, healthy: true, checked: true},
+		{update: down, healthy: true, checked: true},
+		{update: nil, healthy: true, checked: true},
+		{update: down, healthy: true, checked: true},
+		{update: down, healthy: false, checked: true},
+		{update: nil, healthy: false, checked: true},
+		{update: nil, healthy: false, checked: true},
+		{update: down, healthy: false, checked: true},
+		{update: nil, healthy: false, checked: true},
+		{update: nil, healthy: false, checked: true},
+		{update: nil, healthy: true, checked: true},
+	}
+	for i, step := range steps {
+		updater.Update(step.update)
+		err := updater.Check()
+		if (err == nil) != step.healthy || (err != errNotYetChecked) != step.checked {
+			t.Fatalf("step %d: unexpected status: %v", i, err)
+		}
+	}
+
+	if events := registry.RecentEvents(10); len(events) != 2 {
+		t.Errorf("2 transitions were expected: %+v", events)
+	}
+}
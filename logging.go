@@ -0,0 +1,48 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SetLogger makes the registry log the transitions of its checks to logger:
+// becoming unhealthy is logged at the error level and recovering at the info
+// level. Records carry the name and owner of the check, its old and new
+// states, its error when unhealthy and how long it stayed in its old state,
+// since its previous transition or its registration. Only transitions are
+// logged, not every evaluation; see RecentEvents for which checks report them.
+// A nil logger, the default, disables logging.
+func (registry *Registry) SetLogger(logger *slog.Logger) {
+	registry.eventsMu.Lock()
+	defer registry.eventsMu.Unlock()
+	registry.logger = logger
+}
+
+// SetLogger makes the default registry log the transitions of its checks to
+// logger.
+func SetLogger(logger *slog.Logger) {
+	DefaultRegistry.SetLogger(logger)
+}
+
+// logTransition logs change to logger, the check having been in its previous
+// state for d.
+func logTransition(logger *slog.Logger, change StateChange, d time.Duration) {
+	if change.Healthy {
+		logger.LogAttrs(context.Background(), slog.LevelInfo, "health check recovered",
+			slog.String("check", change.Name),
+			slog.String("owner", change.Owner),
+			slog.String("old_state", "unhealthy"),
+			slog.String("new_state", "healthy"),
+			slog.Duration("duration", d))
+		return
+	}
+
+	logger.LogAttrs(context.Background(), slog.LevelError, "health check failed",
+		slog.String("check", change.Name),
+		slog.String("owner", change.Owner),
+		slog.String("old_state", "healthy"),
+		slog.String("new_state", "unhealthy"),
+		slog.Any("error", change.Err),
+		slog.Duration("duration", d))
+}
@@ -0,0 +1,43 @@
+package health
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestSetLogger ensures that transitions, and only transitions, are logged.
+func TestSetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	registry := NewRegistry()
+	registry.SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	updater := NewStatusUpdater()
+	registry.Register("db", updater, WithOwner("storage"))
+	updater.Update(nil)
+	updater.Update(errors.New("down"))
+	updater.Update(errors.New("still down"))
+	updater.Update(nil)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("2 transitions were expected to be logged, got:\n%s", buf.String())
+	}
+
+	var failed, recovered map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &failed); err != nil {
+		t.Fatalf("unexpected error:%v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &recovered); err != nil {
+		t.Fatalf("unexpected error:%v", err)
+	}
+	if failed["level"] != "ERROR" || failed["check"] != "db" || failed["owner"] != "storage" || failed["new_state"] != "unhealthy" || failed["error"] != "down" {
+		t.Errorf("unexpected record for the failure: %v", failed)
+	}
+	if recovered["level"] != "INFO" || recovered["owner"] != "storage" || recovered["new_state"] != "healthy" || recovered["duration"] == nil {
+		t.Errorf("unexpected record for the recovery: %v", recovered)
+	}
+}
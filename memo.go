@@ -0,0 +1,122 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Identifier is implemented by checks whose result can be shared by every
+// check probing the same dependency within a single evaluation of a registry.
+// Checks with equal identities are assumed to be interchangeable.
+type Identifier interface {
+	Checker
+
+	// Identity returns a stable key identifying the probed dependency.
+	Identity() string
+}
+
+// evaluationKey is the context key of the results shared within an
+// evaluation.
+type evaluationKey struct{}
+
+// sharedResults memoizes the results of the Identifier checks run within a
+// single evaluation of a registry.
+type sharedResults struct {
+	mu      sync.Mutex
+	results map[string]*sharedResult
+}
+
+// sharedResult is the result of an Identifier check, available once done is
+// closed.
+type sharedResult struct {
+	done chan struct{}
+	err  error
+}
+
+// identityKey is the context key of the identities of the Identifier checks
+// being run by the caller, innermost first.
+type identityKey struct{}
+
+// identityChain is a list of the identities of nested Identifier checks.
+type identityChain struct {
+	identity string
+	parent   *identityChain
+}
+
+// contains reports whether identity is in the chain.
+func (c *identityChain) contains(identity string) bool {
+	for ; c != nil; c = c.parent {
+		if c.identity == identity {
+			return true
+		}
+	}
+	return false
+}
+
+// newEvaluation returns a context carrying a fresh set of shared results.
+func newEvaluation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, evaluationKey{}, &sharedResults{
+		results: make(map[string]*sharedResult),
+	})
+}
+
+// Evaluate runs check, calling CheckContext with ctx if check implements
+// ContextChecker. When ctx belongs to an evaluation of a registry, a check
+// implementing Identifier runs at most once per evaluation and its result is
+// shared with every other check of the same identity. Composite checks should
+// evaluate their children with Evaluate so that they benefit from this.
+//
+// A check evaluating, through its context, a child of its own identity runs
+// the child rather than wait for its own result. Checks of different
+// identities evaluating each other concurrently still wait for each other
+// forever, and must be avoided.
+func Evaluate(ctx context.Context, check Checker) error {
+	if id, ok := check.(Identifier); ok {
+		if shared, ok := ctx.Value(evaluationKey{}).(*sharedResults); ok {
+			identity := id.Identity()
+			chain, _ := ctx.Value(identityKey{}).(*identityChain)
+			if chain.contains(identity) {
+				// the result being produced by the caller would never
+				// be available to it
+				return evaluate(ctx, check)
+			}
+			return shared.do(identity, func() error {
+				ctx := context.WithValue(ctx, identityKey{}, &identityChain{identity: identity, parent: chain})
+				return evaluate(ctx, check)
+			})
+		}
+	}
+
+	return evaluate(ctx, check)
+}
+
+// evaluate runs check, with ctx if it implements ContextChecker.
+func evaluate(ctx context.Context, check Checker) error {
+	if cc, ok := check.(ContextChecker); ok {
+		return cc.CheckContext(ctx)
+	}
+	return check.Check()
+}
+
+// do returns the result of fn for identity, running it only if no result
+// for identity has been produced or is being produced.
+func (s *sharedResults) do(identity string, fn func() error) error {
+	s.mu.Lock()
+	if r, ok := s.results[identity]; ok {
+		s.mu.Unlock()
+		<-r.done
+		return r.err
+	}
+	// the placeholder error is only seen if fn panics
+	r := &sharedResult{
+		done: make(chan struct{}),
+		err:  errors.New("shared check " + identity + " did not complete"),
+	}
+	s.results[identity] = r
+	s.mu.Unlock()
+
+	defer close(r.done)
+	r.err = fn()
+	return r.err
+}
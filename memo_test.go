@@ -0,0 +1,86 @@
+package health
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// identifiedCheck is an Identifier counting its invocations.
+type identifiedCheck struct {
+	identity string
+	calls    *int32
+}
+
+func (c identifiedCheck) Check() error {
+	atomic.AddInt32(c.calls, 1)
+	return nil
+}
+
+func (c identifiedCheck) Identity() string {
+	return c.identity
+}
+
+// TestSharedResults ensures that checks of the same identity run once per
+// evaluation, even when nested in composite checks.
+func TestSharedResults(t *testing.T) {
+	var dbCalls, cacheCalls int32
+	db := identifiedCheck{identity: "db", calls: &dbCalls}
+	cache := identifiedCheck{identity: "cache", calls: &cacheCalls}
+
+	registry := NewRegistry()
+	registry.Register("db", db)
+	registry.Register("group_a", GroupChecker([]Checker{db, cache}, 0.5, 0.5))
+	registry.Register("group_b", GroupChecker([]Checker{db, cache}, 0.5, 0.5))
+
+	registry.failingChecks()
+	if dbCalls != 1 || cacheCalls != 1 {
+		t.Errorf("shared checks were expected to run once: db ran %d times, cache ran %d times", dbCalls, cacheCalls)
+	}
+
+	registry.failingChecks()
+	if dbCalls != 2 || cacheCalls != 2 {
+		t.Errorf("shared checks were expected to run again in a new evaluation: db ran %d times, cache ran %d times", dbCalls, cacheCalls)
+	}
+}
+
+// nestedIdentifiedCheck is an Identifier evaluating a child of its own
+// identity.
+type nestedIdentifiedCheck struct {
+	child Checker
+}
+
+func (c nestedIdentifiedCheck) Check() error {
+	return c.CheckContext(context.Background())
+}
+
+func (c nestedIdentifiedCheck) CheckContext(ctx context.Context) error {
+	return Evaluate(ctx, c.child)
+}
+
+func (c nestedIdentifiedCheck) Identity() string {
+	return "db"
+}
+
+// TestSharedResultsReentry ensures that a check evaluating a child of its own
+// identity runs the child instead of deadlocking.
+func TestSharedResultsReentry(t *testing.T) {
+	var calls int32
+	registry := NewRegistry()
+	registry.Register("db", nestedIdentifiedCheck{child: identifiedCheck{identity: "db", calls: &calls}})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		registry.failingChecks()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("evaluating a child of the same identity deadlocked")
+	}
+	if calls != 1 {
+		t.Errorf("child was expected to run once, ran %d times", calls)
+	}
+}
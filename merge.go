@@ -0,0 +1,119 @@
+package health
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// inclusionMu serializes the inclusions of registries, so that concurrent
+// calls to Include cannot close a cycle that neither detects.
+var inclusionMu sync.Mutex
+
+// includedRegistry is a registry whose checks are evaluated along with those
+// of the registry including it, see Include.
+type includedRegistry struct {
+	prefix   string
+	registry *Registry
+}
+
+// MergedRegistry returns a registry evaluating the checks of registries, such
+// as those of separately owned modules, each check being named after the
+// index of its registry and its own name, e.g. "0/db". See Include for how the
+// checks of the merged registries are evaluated.
+func MergedRegistry(registries ...*Registry) *Registry {
+	merged := NewRegistry()
+	for i, child := range registries {
+		merged.Include(strconv.Itoa(i), child)
+	}
+	return merged
+}
+
+// Include makes evaluations of the registry, such as those of the status
+// handlers, Report and CheckStatus, evaluate the checks of child as well,
+// concurrently with its own checks, naming them after prefix and their own
+// name, e.g. "billing/db". Included registries keep their own settings, such
+// as timeouts and pools, and remain usable on their own. Operations on
+// individual checks, such as Unregister or ForceResult, only apply to the
+// checks registered with the registry itself. Include panics if child
+// already includes the registry, directly or not, or if the name of one of
+// its checks, once prefixed, is already taken by a check of the registry.
+// Registering a check with such a name with the registry afterwards panics as
+// well.
+func (registry *Registry) Include(prefix string, child *Registry) {
+	inclusionMu.Lock()
+	defer inclusionMu.Unlock()
+
+	if child.includes(registry) {
+		panic("Registry inclusion cycle: " + prefix)
+	}
+
+	names := child.checkNames()
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for _, name := range names {
+		if registry.lookupLocked(prefix+"/"+name) != nil {
+			panic("Check already exists: " + prefix + "/" + name)
+		}
+	}
+	registry.included = append(registry.included, includedRegistry{prefix: prefix, registry: child})
+}
+
+// checkNames returns the names of the checks of the registry, including those
+// of the registries it includes under their prefixed names.
+func (registry *Registry) checkNames() []string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	names := make([]string, 0, len(registry.registeredChecks))
+	for name := range registry.registeredChecks {
+		names = append(names, name)
+	}
+	for _, child := range registry.included {
+		for _, name := range child.registry.checkNames() {
+			names = append(names, child.prefix+"/"+name)
+		}
+	}
+	return names
+}
+
+// lookup returns the named check of the registry or of the registries it
+// includes, or nil if there is none.
+func (registry *Registry) lookup(name string) *registeredCheck {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return registry.lookupLocked(name)
+}
+
+// lookupLocked is like lookup. The caller must hold registry.mu.
+func (registry *Registry) lookupLocked(name string) *registeredCheck {
+	if rc, ok := registry.registeredChecks[name]; ok {
+		return rc
+	}
+	for _, child := range registry.included {
+		if rest, ok := strings.CutPrefix(name, child.prefix+"/"); ok {
+			if rc := child.registry.lookup(rest); rc != nil {
+				return rc
+			}
+		}
+	}
+	return nil
+}
+
+// includes reports whether the registry is other or includes it, directly or
+// not.
+func (registry *Registry) includes(other *Registry) bool {
+	if registry == other {
+		return true
+	}
+
+	registry.mu.RLock()
+	included := registry.included
+	registry.mu.RUnlock()
+	for _, child := range included {
+		if child.registry.includes(other) {
+			return true
+		}
+	}
+	return false
+}
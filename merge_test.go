@@ -0,0 +1,81 @@
+package health
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestMergedRegistry ensures that a merged registry evaluates the checks of
+// every child registry, namespacing their names.
+func TestMergedRegistry(t *testing.T) {
+	billing := NewRegistry()
+	billing.RegisterFunc("db", func() error { return nil })
+	search := NewRegistry()
+	search.RegisterFunc("db", func() error { return errors.New("down") })
+
+	merged := MergedRegistry(billing, search)
+	merged.RegisterFunc("disk", func() error { return nil })
+	status := merged.CheckStatus()
+	if len(status) != 3 || status["0/db"].Err != nil || status["1/db"].Err == nil || status["disk"].Err != nil {
+		t.Errorf("unexpected status of the merged registry: %v", status)
+	}
+
+	// checks registered after the merge are evaluated too
+	billing.RegisterFunc("queue", func() error { return nil })
+	if _, ok := merged.CheckStatus()["0/queue"]; !ok {
+		t.Errorf("check registered with a child after the merge was expected")
+	}
+
+	recorder := httptest.NewRecorder()
+	NewStatusHandler(merged).ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusServiceUnavailable || recorder.Body.String() != `{"1/db":"down"}` {
+		t.Errorf("unexpected response: %d %s", recorder.Code, recorder.Body)
+	}
+}
+
+// TestIncludeCycle ensures that including a registry in itself, directly or
+// not, panics.
+func TestIncludeCycle(t *testing.T) {
+	parent := NewRegistry()
+	child := NewRegistry()
+	parent.Include("child", child)
+
+	defer func() {
+		r := recover()
+		if r == nil || !strings.Contains(r.(string), "cycle") {
+			t.Errorf("an inclusion cycle was expected to panic, got %v", r)
+		}
+	}()
+	child.Include("parent", parent)
+}
+
+// TestIncludeCollision ensures that the prefixed names of the checks of an
+// included registry cannot collide with those of the registry including it.
+func TestIncludeCollision(t *testing.T) {
+	expectPanic := func(name string, f func()) {
+		defer func() {
+			r := recover()
+			if r == nil || !strings.Contains(r.(string), "already exists") {
+				t.Errorf("%s was expected to panic, got %v", name, r)
+			}
+		}()
+		f()
+	}
+
+	parent := NewRegistry()
+	parent.RegisterFunc("billing/db", func() error { return nil })
+	child := NewRegistry()
+	child.RegisterFunc("db", func() error { return nil })
+	expectPanic("including a colliding check", func() { parent.Include("billing", child) })
+
+	parent.Include("search", child)
+	expectPanic("registering a colliding check", func() {
+		parent.RegisterFunc("search/db", func() error { return nil })
+	})
+	if status := parent.CheckStatus(); len(status) != 2 {
+		t.Errorf("unexpected status: %v", status)
+	}
+}
@@ -0,0 +1,34 @@
+package health
+
+// Middleware wraps the check registered with name, returning a Checker
+// evaluated in its place, such as to log or measure every check uniformly.
+// Middleware should run next with Evaluate, so that it receives the context
+// of the evaluation and still shares its results, see Identifier.
+type Middleware func(name string, next Checker) Checker
+
+// Use installs middleware wrapping every check of the registry, including
+// those already registered, from their next evaluation on. Middleware
+// installed first is outermost. Checks are wrapped on every evaluation, which
+// runs the result in the place of the check, within the timeout of the check.
+func (registry *Registry) Use(middleware Middleware) {
+	registry.middlewareMu.Lock()
+	defer registry.middlewareMu.Unlock()
+	registry.middleware = append(registry.middleware, middleware)
+}
+
+// Use installs middleware wrapping every check of the default registry.
+func Use(middleware Middleware) {
+	DefaultRegistry.Use(middleware)
+}
+
+// wrap returns check wrapped with the middleware of the registry.
+func (registry *Registry) wrap(name string, check Checker) Checker {
+	registry.middlewareMu.RLock()
+	middleware := registry.middleware
+	registry.middlewareMu.RUnlock()
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		check = middleware[i](name, check)
+	}
+	return check
+}
@@ -0,0 +1,46 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestUse ensures that middleware wraps every check, including those
+// registered before it was installed, the first installed being outermost.
+func TestUse(t *testing.T) {
+	registry := NewRegistry()
+	var deadline bool
+	registry.RegisterFuncContext("db", func(ctx context.Context) error {
+		_, deadline = ctx.Deadline()
+		return errors.New("down")
+	}, WithTimeout(time.Second))
+
+	var calls []string
+	wrapper := func(label string) Middleware {
+		return func(name string, next Checker) Checker {
+			return CheckFuncContext(func(ctx context.Context) error {
+				calls = append(calls, label+" "+name)
+				if err := Evaluate(ctx, next); err != nil {
+					return errors.New(label + ": " + err.Error())
+				}
+				return nil
+			})
+		}
+	}
+	registry.Use(wrapper("outer"))
+	registry.Use(wrapper("inner"))
+
+	status := registry.CheckStatus()
+	if err := status["db"].Err; err == nil || err.Error() != "outer: inner: down" {
+		t.Errorf("unexpected wrapped error:%v", err)
+	}
+	if strings.Join(calls, ",") != "outer db,inner db" {
+		t.Errorf("unexpected middleware calls: %v", calls)
+	}
+	if !deadline {
+		t.Errorf("the check was expected to receive the context of the evaluation")
+	}
+}
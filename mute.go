@@ -0,0 +1,76 @@
+package health
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// SetEnabled mutes the named check when enabled is false, and unmutes it
+// otherwise. A muted check is not run by evaluations and does not affect the
+// overall health, but keeps its registration and, for periodic checks, its
+// goroutine. It is reported as muted in the Report and the JSON responses of
+// the status handlers. This allows silencing a check during planned
+// maintenance of its dependency. SetEnabled does nothing if no check is
+// registered with name.
+func (registry *Registry) SetEnabled(name string, enabled bool) {
+	registry.setEnabled(name, enabled)
+}
+
+// SetEnabled mutes or unmutes the named check of the default registry.
+func SetEnabled(name string, enabled bool) {
+	DefaultRegistry.SetEnabled(name, enabled)
+}
+
+// setEnabled mutes or unmutes the named check, reporting whether it is
+// registered.
+func (registry *Registry) setEnabled(name string, enabled bool) bool {
+	registry.mu.RLock()
+	rc, ok := registry.registeredChecks[name]
+	registry.mu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.muted = !enabled
+	return true
+}
+
+// isMuted reports whether the check was muted with SetEnabled.
+func (rc *registeredCheck) isMuted() bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.muted
+}
+
+// MuteHandler returns a handler muting and unmuting the checks of registry at
+// runtime, see SetEnabled. It serves POST requests with a "check" parameter
+// naming the check and an "enabled" boolean parameter, e.g.
+// "check=db&enabled=false". Requests are only served when authorized returns
+// true for them: as muting checks alters the health of the service, a nil
+// authorized denies every request rather than allowing anyone to. The handler
+// is not mounted by default.
+func MuteHandler(registry *Registry, authorized func(*http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if authorized == nil || !authorized(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		enabled, err := strconv.ParseBool(r.FormValue("enabled"))
+		if err != nil {
+			http.Error(w, "enabled must be a boolean", http.StatusBadRequest)
+			return
+		}
+		if !registry.setEnabled(r.FormValue("check"), enabled) {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,99 @@
+package health
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestSetEnabled ensures that muted checks are not run and do not affect the
+// overall health until they are enabled again.
+func TestSetEnabled(t *testing.T) {
+	registry := NewRegistry()
+	calls := 0
+	registry.RegisterFunc("db", func() error {
+		calls++
+		return errors.New("down")
+	})
+	registry.SetEnabled("db", false)
+	registry.SetEnabled("unknown", false)
+
+	handler := NewStatusHandler(registry)
+	req := httptest.NewRequest("GET", "/debug/health", nil)
+	req.Header.Set("Accept", "application/json")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK || recorder.Body.String() != `{"status":"healthy","checks":{},"muted":["db"]}` {
+		t.Errorf("unexpected response with a muted check: %d %s", recorder.Code, recorder.Body)
+	}
+	if cr := registry.Report().Checks["db"]; !cr.Muted || cr.Status != "ok" {
+		t.Errorf("unexpected report of the muted check: %+v", cr)
+	}
+	if calls != 0 {
+		t.Errorf("muted check was not expected to run, ran %d times", calls)
+	}
+
+	registry.SetEnabled("db", true)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Did not get a 503 once the check was enabled, got %d", recorder.Code)
+	}
+}
+
+// TestMuteHandler ensures that checks can be muted at runtime by authorized
+// requests.
+func TestMuteHandler(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("db", func() error { return errors.New("down") })
+	handler := MuteHandler(registry, func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "secret"
+	})
+
+	serve := func(method, body, auth string) int {
+		req := httptest.NewRequest(method, "/debug/health/mute", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", auth)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	for _, tc := range []struct {
+		method, body, auth string
+		code               int
+	}{
+		{"GET", "", "secret", http.StatusMethodNotAllowed},
+		{"POST", "check=db&enabled=false", "wrong", http.StatusUnauthorized},
+		{"POST", "check=db&enabled=maybe", "secret", http.StatusBadRequest},
+		{"POST", "check=unknown&enabled=false", "secret", http.StatusNotFound},
+		{"POST", "check=db&enabled=false", "secret", http.StatusNoContent},
+	} {
+		if code := serve(tc.method, tc.body, tc.auth); code != tc.code {
+			t.Errorf("%s %q: expected %d, got %d", tc.method, tc.body, tc.code, code)
+		}
+	}
+	if failing := registry.failingChecks(); len(failing) != 0 {
+		t.Errorf("check was expected to be muted: %v", failing)
+	}
+}
+
+// TestMuteHandlerNilAuthorized ensures that a mute handler without an
+// authorizer denies every request.
+func TestMuteHandlerNilAuthorized(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("db", func() error { return errors.New("down") })
+
+	req := httptest.NewRequest("POST", "/debug/health/mute", strings.NewReader("check=db&enabled=false"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	MuteHandler(registry, nil).ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("expected %d, got %d", http.StatusUnauthorized, recorder.Code)
+	}
+	if failing := registry.failingChecks(); len(failing) != 1 {
+		t.Errorf("check was not expected to be muted: %v", failing)
+	}
+}
@@ -0,0 +1,46 @@
+package health
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Nagios plugin exit codes.
+const (
+	NagiosOK       = 0
+	NagiosWarning  = 1
+	NagiosCritical = 2
+)
+
+// NagiosOutput evaluates every check of the registry and returns a summary
+// line in the format of Nagios plugins along with the matching exit code:
+// NagiosOK when healthy, NagiosWarning when degraded and NagiosCritical when
+// unhealthy. Failing checks are listed by name, critical ones first.
+// Informational checks and fail-open timeouts are left out.
+func (registry *Registry) NagiosOutput() (string, int) {
+	report := registry.Report()
+
+	var critical, warning []string
+	for name, cr := range report.Checks {
+		if cr.Informational || cr.FailedOpen {
+			continue
+		}
+		switch cr.Status {
+		case SeverityCritical.String():
+			critical = append(critical, name+": "+cr.Error)
+		case SeverityWarning.String():
+			warning = append(warning, name+": "+cr.Error)
+		}
+	}
+	sort.Strings(critical)
+	sort.Strings(warning)
+
+	switch report.Status {
+	case StatusUnhealthy:
+		return "HEALTH CRITICAL - " + strings.Join(append(critical, warning...), ", "), NagiosCritical
+	case StatusDegraded:
+		return "HEALTH WARNING - " + strings.Join(warning, ", "), NagiosWarning
+	}
+	return fmt.Sprintf("HEALTH OK - %d checks passing", len(report.Checks)), NagiosOK
+}
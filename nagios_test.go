@@ -0,0 +1,34 @@
+package health
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestNagiosOutput ensures that the Nagios summary and exit code follow the
+// state of the registry.
+func TestNagiosOutput(t *testing.T) {
+	registry := NewRegistry()
+	db := NewStatusUpdater()
+	registry.Register("db", db)
+	cache := NewStatusUpdater()
+	registry.Register("cache", cache)
+
+	for _, tc := range []struct {
+		db, cache error
+		output    string
+		code      int
+	}{
+		{nil, nil, "HEALTH OK - 2 checks passing", NagiosOK},
+		{nil, &StatusError{Severity: SeverityWarning, Err: errors.New("slow")}, "HEALTH WARNING - cache: slow", NagiosWarning},
+		{errors.New("refused"), &StatusError{Severity: SeverityWarning, Err: errors.New("slow")}, "HEALTH CRITICAL - db: refused, cache: slow", NagiosCritical},
+	} {
+		db.Update(tc.db)
+		cache.Update(tc.cache)
+
+		output, code := registry.NagiosOutput()
+		if output != tc.output || code != tc.code {
+			t.Errorf("unexpected Nagios output: %q (%d) != %q (%d)", output, code, tc.output, tc.code)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Package otelhealth instruments health checks with OpenTelemetry tracing. It
+// lives in its own package so that users of the health package do not depend
+// on OpenTelemetry.
+package otelhealth
+
+import (
+	"context"
+
+	"github.com/docker/distribution/health"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracedChecker returns a Checker running inner within a span of tracer named
+// after the check, so that the latency and failures of each check show in
+// the tracing backend. A failure is recorded on the span and sets its status
+// to error. The span is a child of the one in the context of the evaluation,
+// if any, and inner is evaluated with health.Evaluate within that context,
+// sharing its result with other checks of the same identity.
+func TracedChecker(tracer trace.Tracer, name string, inner health.Checker) health.Checker {
+	return &tracedChecker{tracer: tracer, name: name, inner: inner}
+}
+
+// tracedChecker is the ContextChecker returned by TracedChecker.
+type tracedChecker struct {
+	tracer trace.Tracer
+	name   string
+	inner  health.Checker
+}
+
+// Check implements the health.Checker interface.
+func (tc *tracedChecker) Check() error {
+	return tc.CheckContext(context.Background())
+}
+
+// CheckContext implements the health.ContextChecker interface.
+func (tc *tracedChecker) CheckContext(ctx context.Context) error {
+	ctx, span := tc.tracer.Start(ctx, tc.name, trace.WithAttributes(attribute.String("health.check", tc.name)))
+	defer span.End()
+
+	err := health.Evaluate(ctx, tc.inner)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
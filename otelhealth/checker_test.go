@@ -0,0 +1,86 @@
+package otelhealth
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/docker/distribution/health"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracedChecker(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("health")
+
+	registry := health.NewRegistry()
+	registry.Register("ok", TracedChecker(tracer, "ok", health.CheckFunc(func() error { return nil })))
+	var parent trace.SpanContext
+	registry.Register("failing", TracedChecker(tracer, "failing", health.CheckFuncContext(func(ctx context.Context) error {
+		parent = trace.SpanContextFromContext(ctx)
+		return errors.New("down")
+	})))
+
+	status := registry.CheckStatus()
+	if status["failing"].Err == nil || status["ok"].Err != nil {
+		t.Fatalf("unexpected status: %v", status)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 ended spans, got %d", len(recorder.Ended()))
+	}
+	if s := spans["ok"].Status(); s.Code != codes.Unset {
+		t.Errorf("unexpected status of passing check span: %+v", s)
+	}
+	failing := spans["failing"]
+	if s := failing.Status(); s.Code != codes.Error || s.Description != "down" {
+		t.Errorf("unexpected status of failing check span: %+v", s)
+	}
+	if len(failing.Events()) != 1 || failing.Events()[0].Name != "exception" {
+		t.Errorf("expected the error to be recorded on the span, got events %v", failing.Events())
+	}
+	if parent.SpanID() != failing.SpanContext().SpanID() {
+		t.Errorf("expected the span to be passed to the check in its context")
+	}
+}
+
+// identifiedCheck is a check of the dependency identified by identity,
+// counting its runs.
+type identifiedCheck struct {
+	identity string
+	runs     *int32
+}
+
+func (ic identifiedCheck) Check() error {
+	atomic.AddInt32(ic.runs, 1)
+	return nil
+}
+
+func (ic identifiedCheck) Identity() string {
+	return ic.identity
+}
+
+func TestTracedCheckerIdentifier(t *testing.T) {
+	tracer := sdktrace.NewTracerProvider().Tracer("health")
+
+	var runs int32
+	registry := health.NewRegistry()
+	registry.Register("primary", TracedChecker(tracer, "primary", identifiedCheck{"db", &runs}))
+	registry.Register("replica", TracedChecker(tracer, "replica", identifiedCheck{"db", &runs}))
+
+	status := registry.CheckStatus()
+	if status["primary"].Err != nil || status["replica"].Err != nil {
+		t.Fatalf("unexpected status: %v", status)
+	}
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Errorf("expected the shared dependency to be checked once, got %d", n)
+	}
+}
@@ -0,0 +1,59 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// panicError is reported for a check that panicked. Only the recovered value
+// is part of the error message; the stack trace is kept out of it so that it
+// never reaches the default status output.
+type panicError struct {
+	value interface{}
+	stack []byte
+}
+
+// Error implements the error interface.
+func (e *panicError) Error() string {
+	return fmt.Sprintf("check panicked: %v", e.value)
+}
+
+// call invokes check, the checker of rc as wrapped by middleware, converting
+// a panic into a failure and recording its stack trace.
+func (rc *registeredCheck) call(ctx context.Context, check Checker) error {
+	err := evaluateSafely(ctx, check)
+	if pe, ok := err.(*panicError); ok {
+		rc.mu.Lock()
+		rc.lastPanic = string(pe.stack)
+		rc.mu.Unlock()
+	}
+	return err
+}
+
+// evaluateSafely evaluates check, converting a panic into a *panicError.
+func evaluateSafely(ctx context.Context, check Checker) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &panicError{value: v, stack: debug.Stack()}
+		}
+	}()
+
+	return Evaluate(ctx, check)
+}
+
+// LastPanic returns the stack trace of the last panic of the named check, or
+// an empty string if it never panicked. The stack trace is only exposed here
+// and by TraceHandler, as it may reveal details that should not be public.
+func (registry *Registry) LastPanic(name string) string {
+	registry.mu.RLock()
+	rc, ok := registry.registeredChecks[name]
+	registry.mu.RUnlock()
+	if !ok {
+		return ""
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.lastPanic
+}
@@ -0,0 +1,54 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestCheckPanic ensures that a panicking check is reported as failed, and
+// that its stack trace is only available through the debug outputs.
+func TestCheckPanic(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("panicking", func() error {
+		panic("out of cheese")
+	})
+
+	status := registry.failingChecks()
+	if status["panicking"] != "check panicked: out of cheese" {
+		t.Errorf("unexpected status: %q", status["panicking"])
+	}
+
+	stack := registry.LastPanic("panicking")
+	if !strings.Contains(stack, "panic_test.go") {
+		t.Errorf("stack trace of the panic was expected, got: %q", stack)
+	}
+
+	recorder := httptest.NewRecorder()
+	NewStatusHandler(registry).ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/health", nil))
+	if strings.Contains(recorder.Body.String(), "panic_test.go") {
+		t.Errorf("status output was not expected to include the stack trace: %s", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	TraceHandler(registry, func(*http.Request) bool { return true }).ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/health/trace?trace=1", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "panic_test.go") {
+		t.Errorf("trace output was expected to include the stack trace: %s", recorder.Body.String())
+	}
+}
+
+// TestPeriodicCheckPanic ensures that a periodic check panicking in its own
+// goroutine is reported as failed rather than crashing the process.
+func TestPeriodicCheckPanic(t *testing.T) {
+	registry := NewRegistry()
+	checker := DrivenChecker(CheckFunc(func() error {
+		panic("out of cheese")
+	}))
+	registry.Register("panicking", checker)
+
+	registry.Tick()
+	if status := registry.failingChecks(); status["panicking"] != "check panicked: out of cheese" {
+		t.Errorf("unexpected status: %q", status["panicking"])
+	}
+}
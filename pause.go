@@ -0,0 +1,45 @@
+package health
+
+// pausable is implemented by periodic checks whose probing can be suspended.
+type pausable interface {
+	setPaused(paused bool)
+	isPaused() bool
+}
+
+// PauseAll stops probing the periodic checks of the registry, such as those
+// created by PeriodicChecker, until ResumeAll is called. Paused checks keep
+// reporting their last result and are flagged as paused in the Report, which
+// avoids alert storms during planned maintenance of their dependencies.
+// Periodic checks registered while the registry is paused start paused.
+func (registry *Registry) PauseAll() {
+	registry.setPaused(true)
+}
+
+// ResumeAll resumes probing the periodic checks paused by PauseAll.
+func (registry *Registry) ResumeAll() {
+	registry.setPaused(false)
+}
+
+// setPaused pauses or resumes every periodic check of the registry.
+func (registry *Registry) setPaused(paused bool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.paused = paused
+	for _, rc := range registry.registeredChecks {
+		if p, ok := rc.checker.(pausable); ok {
+			p.setPaused(paused)
+		}
+	}
+}
+
+// PauseAll stops probing the periodic checks of the default registry until
+// ResumeAll is called.
+func PauseAll() {
+	DefaultRegistry.PauseAll()
+}
+
+// ResumeAll resumes probing the periodic checks of the default registry.
+func ResumeAll() {
+	DefaultRegistry.ResumeAll()
+}
@@ -0,0 +1,51 @@
+package health
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestPauseAll ensures that paused periodic checks keep their last result and
+// stop probing until resumed.
+func TestPauseAll(t *testing.T) {
+	registry := NewRegistry()
+
+	var failing atomic.Bool
+	registry.Register("periodic", PeriodicChecker(CheckFunc(func() error {
+		if failing.Load() {
+			return errors.New("down")
+		}
+		return nil
+	}), time.Millisecond))
+
+	registry.PauseAll()
+	failing.Store(true)
+	time.Sleep(10 * time.Millisecond)
+
+	report := registry.Report()
+	if cr := report.Checks["periodic"]; cr.Status != "ok" || !cr.Paused {
+		t.Errorf("paused check was expected to keep its last result: %+v", cr)
+	}
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json")
+	NewStatusHandler(registry).ServeHTTP(recorder, req)
+	if body := recorder.Body.String(); !strings.Contains(body, `"paused":["periodic"]`) {
+		t.Errorf("paused check was expected in the status response: %s", body)
+	}
+
+	registry.ResumeAll()
+	for i := 0; registry.Report().Checks["periodic"].Status == "ok"; i++ {
+		if i == 100 {
+			t.Fatalf("resumed check was expected to report the failure")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if cr := registry.Report().Checks["periodic"]; cr.Paused {
+		t.Errorf("resumed check was not expected to be reported as paused: %+v", cr)
+	}
+}
@@ -0,0 +1,141 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// A Pool is a fixed set of goroutines running the checks of the registries it
+// is assigned to with SetPool. A pool bounds the number of checks running
+// concurrently and reuses its goroutines across evaluations, rather than
+// spawning goroutines for every evaluation. A panic in a task is recovered
+// and does not take its goroutine down.
+//
+// A pool may be shared by several registries. Checks with a timeout are still
+// watched by a goroutine of their own, so that they can be abandoned.
+// Evaluations nested in a check running on the pool, such as those of the
+// registries included with Include, or those a check starts with the context
+// it was given, run their checks in goroutines of their own, so that they do
+// not wait for the busy goroutines of the pool.
+type Pool struct {
+	size  int
+	tasks chan func()
+
+	mu     sync.RWMutex
+	closed bool
+
+	busy      atomic.Int64
+	queued    atomic.Int64
+	completed atomic.Uint64
+	panics    atomic.Uint64
+}
+
+// NewPool starts a pool of size goroutines. It panics if size is not
+// positive.
+func NewPool(size int) *Pool {
+	if size <= 0 {
+		panic("health: pool size must be positive")
+	}
+
+	p := &Pool{size: size, tasks: make(chan func())}
+	for i := 0; i < size; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// PoolStats describes the utilization of a pool at a point in time.
+type PoolStats struct {
+	// Size is the number of goroutines of the pool.
+	Size int
+
+	// Busy is the number of goroutines currently running a check.
+	Busy int
+
+	// Queued is the number of checks waiting for a goroutine to be
+	// available.
+	Queued int
+
+	// Completed is the number of checks run by the pool.
+	Completed uint64
+
+	// Panics is the number of checks run by the pool that panicked.
+	Panics uint64
+}
+
+// Stats returns the current utilization statistics of the pool.
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Size:      p.size,
+		Busy:      int(p.busy.Load()),
+		Queued:    int(p.queued.Load()),
+		Completed: p.completed.Load(),
+		Panics:    p.panics.Load(),
+	}
+}
+
+// Close stops the goroutines of the pool once they completed their current
+// task. Registries still using the pool then run their checks one after the
+// other.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+}
+
+// submit runs task on the first goroutine of the pool available, waiting for
+// one if they are all busy, or right away if the pool is closed.
+func (p *Pool) submit(task func()) {
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		p.run(task)
+		return
+	}
+	defer p.mu.RUnlock()
+	p.queued.Add(1)
+	p.tasks <- task
+}
+
+// poolKey is the context key of the pool running the check an evaluation is
+// nested in.
+type poolKey struct{}
+
+// onPool reports whether ctx is that of a check running on p.
+func onPool(ctx context.Context, p *Pool) bool {
+	running, _ := ctx.Value(poolKey{}).(*Pool)
+	return running == p
+}
+
+// work runs the tasks submitted to the pool until it is closed.
+func (p *Pool) work() {
+	for task := range p.tasks {
+		p.queued.Add(-1)
+		p.busy.Add(1)
+		p.run(task)
+		p.busy.Add(-1)
+		p.completed.Add(1)
+	}
+}
+
+// run runs task, recovering from any panic.
+func (p *Pool) run(task func()) {
+	defer func() {
+		if recover() != nil {
+			p.panics.Add(1)
+		}
+	}()
+	task()
+}
+
+// SetPool makes the registry evaluate its checks on p. A nil pool, the
+// default, runs every check of an evaluation in a goroutine of its own.
+func (registry *Registry) SetPool(p *Pool) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.pool = p
+}
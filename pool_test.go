@@ -0,0 +1,100 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestPool ensures that a registry evaluates its checks concurrently on its
+// pool, without exceeding the size of the pool.
+func TestPool(t *testing.T) {
+	pool := NewPool(2)
+	defer pool.Close()
+
+	registry := NewRegistry()
+	registry.SetPool(pool)
+
+	var (
+		mu              sync.Mutex
+		running, maxRun int
+	)
+	for i := 0; i < 6; i++ {
+		registry.RegisterFunc(fmt.Sprint("check", i), func() error {
+			mu.Lock()
+			running++
+			if running > maxRun {
+				maxRun = running
+			}
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return errors.New("down")
+		})
+	}
+	registry.RegisterFunc("panicking", func() error {
+		panic("boom")
+	})
+
+	if status := registry.failingChecks(); len(status) != 7 {
+		t.Errorf("every check was expected to fail: %v", status)
+	}
+	if maxRun != 2 {
+		t.Errorf("checks were expected to run two at a time, got %d", maxRun)
+	}
+
+	// the pool accounts for a check once its result has been handed over
+	stats := pool.Stats()
+	for i := 0; i < 100 && stats.Completed != 7; i++ {
+		time.Sleep(time.Millisecond)
+		stats = pool.Stats()
+	}
+	if stats.Size != 2 || stats.Busy != 0 || stats.Queued != 0 || stats.Completed != 7 {
+		t.Errorf("unexpected pool stats: %+v", stats)
+	}
+}
+
+// TestPoolNested ensures that an evaluation nested in a check running on a
+// pool does not wait for the busy goroutines of the pool, and that a closed
+// pool still runs the checks.
+func TestPoolNested(t *testing.T) {
+	pool := NewPool(1)
+
+	inner := NewRegistry()
+	inner.SetPool(pool)
+	inner.RegisterFunc("db", func() error { return errors.New("down") })
+	registry := NewRegistry()
+	registry.SetPool(pool)
+	registry.RegisterFuncContext("inner", func(ctx context.Context) error {
+		if status := inner.ReportContext(ctx).Status; status != StatusHealthy {
+			return errors.New("inner registry is " + status)
+		}
+		return nil
+	})
+
+	done := make(chan map[string]string)
+	go func() {
+		done <- registry.failingChecks()
+	}()
+	select {
+	case status := <-done:
+		if status["inner"] != "inner registry is unhealthy" {
+			t.Errorf("nested evaluation was expected to report the inner failure: %v", status)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("nested evaluation deadlocked on the pool")
+	}
+
+	pool.Close()
+	pool.Close()
+	if status := registry.failingChecks(); len(status) != 1 {
+		t.Errorf("checks were expected to run on a closed pool: %v", status)
+	}
+}
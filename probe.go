@@ -0,0 +1,92 @@
+package health
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// Main runs every check of registry once, prints a concise result and exits
+// with status 0 if all checks passed or 1 otherwise, as expected from a
+// container HEALTHCHECK command. It is meant to be called from the main
+// function of a small probe binary and parses the following flags from the
+// command line:
+//
+//	-verbose   print the error of every failing check
+//	-timeout   overall time allowed for the checks to complete (default 10s)
+//	-nagios    print the result and exit like a Nagios plugin, see NagiosOutput
+func Main(registry *Registry) {
+	os.Exit(runMain(registry, os.Args[1:], os.Stdout))
+}
+
+// runMain implements Main, returning the exit status instead of exiting.
+func runMain(registry *Registry, args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	flags.SetOutput(out)
+	verbose := flags.Bool("verbose", false, "print the error of every failing check")
+	timeout := flags.Duration("timeout", 10*time.Second, "overall time allowed for the checks to complete")
+	nagios := flags.Bool("nagios", false, "print the result and exit like a Nagios plugin")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *nagios {
+		return runNagios(registry, *timeout, out)
+	}
+
+	statusc := make(chan map[string]string, 1)
+	go func() {
+		statusc <- registry.failingChecks()
+	}()
+
+	var status map[string]string
+	select {
+	case status = <-statusc:
+	case <-time.After(*timeout):
+		fmt.Fprintf(out, "unhealthy: checks did not complete within %v\n", *timeout)
+		return 1
+	}
+
+	if len(status) == 0 {
+		fmt.Fprintln(out, "healthy")
+		return 0
+	}
+
+	fmt.Fprintf(out, "unhealthy: %d checks failing\n", len(status))
+	if *verbose {
+		names := make([]string, 0, len(status))
+		for name := range status {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(out, "%s: %s\n", name, status[name])
+		}
+	}
+	return 1
+}
+
+// runNagios prints the Nagios output of registry and returns its exit code.
+func runNagios(registry *Registry, timeout time.Duration, out io.Writer) int {
+	type result struct {
+		output string
+		code   int
+	}
+	resultc := make(chan result, 1)
+	go func() {
+		output, code := registry.NagiosOutput()
+		resultc <- result{output, code}
+	}()
+
+	select {
+	case r := <-resultc:
+		fmt.Fprintln(out, r.output)
+		return r.code
+	case <-time.After(timeout):
+		fmt.Fprintf(out, "HEALTH CRITICAL - checks did not complete within %v\n", timeout)
+		return NagiosCritical
+	}
+}
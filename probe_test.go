@@ -0,0 +1,42 @@
+package health
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestRunMain ensures that the probe entrypoint reports the health of the
+// registry through its output and exit status.
+func TestRunMain(t *testing.T) {
+	registry := NewRegistry()
+	updater := NewStatusUpdater()
+	registry.Register("db", updater)
+
+	var out bytes.Buffer
+	if code := runMain(registry, nil, &out); code != 0 || out.String() != "healthy\n" {
+		t.Errorf("unexpected result when healthy: %d %q", code, out.String())
+	}
+
+	updater.Update(errors.New("connection refused"))
+	out.Reset()
+	if code := runMain(registry, []string{"-verbose"}, &out); code != 1 || out.String() != "unhealthy: 1 checks failing\ndb: connection refused\n" {
+		t.Errorf("unexpected result when unhealthy: %d %q", code, out.String())
+	}
+
+	out.Reset()
+	if code := runMain(registry, []string{"-nagios"}, &out); code != NagiosCritical || out.String() != "HEALTH CRITICAL - db: connection refused\n" {
+		t.Errorf("unexpected Nagios result: %d %q", code, out.String())
+	}
+
+	block := make(chan struct{})
+	defer close(block)
+	registry.RegisterFunc("hung", func() error {
+		<-block
+		return nil
+	})
+	out.Reset()
+	if code := runMain(registry, []string{"-timeout", "10ms"}, &out); code != 1 {
+		t.Errorf("unexpected result when timing out: %d %q", code, out.String())
+	}
+}
@@ -0,0 +1,56 @@
+// Package promhealth exposes the checks of a health registry as Prometheus
+// metrics. It lives in its own package so that users of the health package do
+// not depend on the Prometheus client.
+package promhealth
+
+import (
+	"github.com/docker/distribution/health"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	statusDesc = prometheus.NewDesc(
+		"health_check_status",
+		"Whether the check passed during the last collection (1) or not (0).",
+		[]string{"check"}, nil,
+	)
+	transitionsDesc = prometheus.NewDesc(
+		"health_check_transitions_total",
+		"Number of times the check became healthy or unhealthy.",
+		[]string{"check"}, nil,
+	)
+)
+
+// PrometheusCollector returns a collector exposing, for every check of
+// registry labeled by its name, a gauge set to 1 when it passes and 0
+// otherwise, and a counter of its transitions. Every collection evaluates the
+// registry, periodic checks only reporting their last result. Transitions are
+// counted for the checks reporting them, such as periodic checks; see
+// health.Registry.RecentEvents.
+func PrometheusCollector(registry *health.Registry) prometheus.Collector {
+	return &collector{registry: registry}
+}
+
+// collector is the prometheus.Collector returned by PrometheusCollector.
+type collector struct {
+	registry *health.Registry
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- statusDesc
+	ch <- transitionsDesc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	transitions := c.registry.TransitionCounts()
+	for name, r := range c.registry.CheckStatus() {
+		value := 1.0
+		if r.Err != nil {
+			value = 0
+		}
+		ch <- prometheus.MustNewConstMetric(statusDesc, prometheus.GaugeValue, value, name)
+		ch <- prometheus.MustNewConstMetric(transitionsDesc, prometheus.CounterValue, float64(transitions[name]), name)
+	}
+}
@@ -0,0 +1,35 @@
+package promhealth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/docker/distribution/health"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPrometheusCollector(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.RegisterFunc("db", func() error { return nil })
+	updater := health.NewStatusUpdater()
+	registry.Register("queue", updater)
+	updater.Update(nil)
+	updater.Update(errors.New("down"))
+	updater.Update(nil)
+	updater.Update(errors.New("down again"))
+
+	expected := `
+# HELP health_check_status Whether the check passed during the last collection (1) or not (0).
+# TYPE health_check_status gauge
+health_check_status{check="db"} 1
+health_check_status{check="queue"} 0
+# HELP health_check_transitions_total Number of times the check became healthy or unhealthy.
+# TYPE health_check_transitions_total counter
+health_check_transitions_total{check="db"} 0
+health_check_transitions_total{check="queue"} 3
+`
+	if err := testutil.CollectAndCompare(PrometheusCollector(registry), strings.NewReader(expected)); err != nil {
+		t.Errorf("unexpected metrics: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package health
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WithRateLimit bounds how often requests to the handler run the checks to
+// perSecond on average, with bursts of up to burst requests, so that a client
+// scraping the handler aggressively cannot overload the dependencies the checks
+// probe. Requests past the limit are not rejected: they get the results of the
+// last evaluation, so that monitors polling at a normal cadence are never
+// throttled. It panics if perSecond is not positive.
+func WithRateLimit(perSecond float64, burst int) StatusOption {
+	if perSecond <= 0 {
+		panic("rate limit must be positive: " + strconv.FormatFloat(perSecond, 'g', -1, 64))
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return func(c *statusConfig) {
+		c.limiter = &rateLimiter{
+			perSecond: perSecond,
+			burst:     float64(burst),
+			tokens:    float64(burst),
+		}
+	}
+}
+
+// rateLimiter is a token bucket limiting how often a handler runs the checks,
+// keeping the last results to serve past the limit.
+type rateLimiter struct {
+	perSecond float64
+	burst     float64
+
+	mu      sync.Mutex
+	tokens  float64
+	last    time.Time                    // when tokens was last refilled
+	results map[string]map[string]result // last results, by group
+}
+
+// evaluate returns the results of evaluate for group, or its last results if
+// the rate limit is exceeded.
+func (l *rateLimiter) evaluate(group string, evaluate func() map[string]result) map[string]result {
+	l.mu.Lock()
+	allowed := l.allow()
+	cached, ok := l.results[group]
+	if allowed || !ok {
+		l.mu.Unlock()
+		results := evaluate()
+
+		l.mu.Lock()
+		if l.results == nil {
+			l.results = make(map[string]map[string]result)
+		}
+		l.results[group] = copyResults(results)
+		l.mu.Unlock()
+		return results
+	}
+	l.mu.Unlock()
+	return copyResults(cached)
+}
+
+// allow takes a token from the bucket, if any is left. l.mu must be held.
+func (l *rateLimiter) allow() bool {
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.perSecond
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// copyResults returns a copy of results, which the handlers modify.
+func copyResults(results map[string]result) map[string]result {
+	c := make(map[string]result, len(results))
+	for name, r := range results {
+		c[name] = r
+	}
+	return c
+}
@@ -0,0 +1,38 @@
+package health
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// TestWithRateLimit ensures that requests past the rate limit get the last
+// results instead of running the checks.
+func TestWithRateLimit(t *testing.T) {
+	registry := NewRegistry()
+	var runs atomic.Int64
+	registry.Register("down", CheckFunc(func() error {
+		runs.Add(1)
+		return errors.New("down")
+	}))
+	handler := NewStatusHandler(registry, WithRateLimit(0.001, 2))
+
+	for i := 0; i < 10; i++ {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		if recorder.Code != http.StatusServiceUnavailable {
+			t.Errorf("request %d did not get a 503: %d", i, recorder.Code)
+		}
+	}
+	if n := runs.Load(); n != 2 {
+		t.Errorf("check was expected to run for the burst only, ran %d times", n)
+	}
+
+	// other handlers are not limited
+	NewStatusHandler(registry).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if n := runs.Load(); n != 3 {
+		t.Errorf("check was expected to run for an unlimited handler, ran %d times", n)
+	}
+}
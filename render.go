@@ -0,0 +1,69 @@
+package health
+
+import "net/http"
+
+// ResponseRenderer writes the body of the responses of the status handlers,
+// such as an HTML status page, given the result of every evaluated check, nil
+// for those passing. The status code is chosen by the handler: it is sent on
+// the first call to Write or WriteHeader of w, whatever code is passed to the
+// latter, so the renderer may set headers such as Content-Type beforehand.
+type ResponseRenderer func(w http.ResponseWriter, results map[string]error)
+
+// SetResponseRenderer makes the status handlers serving the registry delegate
+// writing their response bodies to renderer. A nil renderer restores the
+// default JSON bodies.
+func (registry *Registry) SetResponseRenderer(renderer ResponseRenderer) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.renderer = renderer
+}
+
+// SetResponseRenderer sets the renderer of the status handlers serving the
+// default registry.
+func SetResponseRenderer(renderer ResponseRenderer) {
+	DefaultRegistry.SetResponseRenderer(renderer)
+}
+
+// responseRenderer returns the renderer of the registry, nil if none is set.
+func (registry *Registry) responseRenderer() ResponseRenderer {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return registry.renderer
+}
+
+// render completes the request with a response with the given status code,
+// the body being written by renderer.
+func render(w http.ResponseWriter, status int, results map[string]result, renderer ResponseRenderer) {
+	errs := make(map[string]error, len(results))
+	for name, r := range results {
+		errs[name] = r.err
+	}
+
+	rw := &renderWriter{ResponseWriter: w, status: status}
+	renderer(rw, errs)
+	rw.WriteHeader(status)
+}
+
+// renderWriter is the http.ResponseWriter passed to ResponseRenderers,
+// enforcing the status code chosen by the handler.
+type renderWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader implements the http.ResponseWriter interface, sending the
+// status code chosen by the handler instead of code.
+func (rw *renderWriter) WriteHeader(code int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(rw.status)
+}
+
+// Write implements the http.ResponseWriter interface.
+func (rw *renderWriter) Write(p []byte) (int, error) {
+	rw.WriteHeader(rw.status)
+	return rw.ResponseWriter.Write(p)
+}
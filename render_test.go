@@ -0,0 +1,58 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+)
+
+// TestResponseRenderer ensures that the status handlers delegate writing
+// their bodies to the renderer of the registry, keeping their status codes.
+func TestResponseRenderer(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("db", func() error { return errors.New("down") })
+	registry.RegisterFunc("cache", func() error { return nil })
+	registry.SetResponseRenderer(func(w http.ResponseWriter, results map[string]error) {
+		names := make([]string, 0, len(results))
+		for name := range results {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusTeapot) // overridden by the handler
+		for _, name := range names {
+			fmt.Fprintf(w, "%s: %v\n", name, results[name])
+		}
+	})
+
+	recorder := httptest.NewRecorder()
+	NewStatusHandler(registry).ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Did not get a 503, got %d", recorder.Code)
+	}
+	if ct := recorder.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("unexpected content type: %q", ct)
+	}
+	if body := recorder.Body.String(); body != "cache: <nil>\ndb: down\n" {
+		t.Errorf("unexpected body: %q", body)
+	}
+
+	// a renderer writing nothing still gets the status code sent
+	registry.SetResponseRenderer(func(w http.ResponseWriter, results map[string]error) {})
+	recorder = httptest.NewRecorder()
+	NewStatusHandler(registry, WithUnhealthyStatus(http.StatusInternalServerError)).ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusInternalServerError || recorder.Body.Len() != 0 {
+		t.Errorf("unexpected response: %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	registry.SetResponseRenderer(nil)
+	recorder = httptest.NewRecorder()
+	NewStatusHandler(registry).ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if body := recorder.Body.String(); body != `{"db":"down"}` {
+		t.Errorf("unexpected default body: %q", body)
+	}
+}
@@ -0,0 +1,226 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Overall statuses of a Report.
+const (
+	StatusHealthy   = "healthy"
+	StatusDegraded  = "degraded"
+	StatusUnhealthy = "unhealthy"
+)
+
+// Report is the detailed outcome of evaluating every check of a registry,
+// passing or not. Its JSON encoding is a stable schema that consumers can rely
+// on; incompatible changes to it will only be made under a new schema:
+//
+//	{
+//	  "status": "healthy" | "degraded" | "unhealthy",
+//	  "checks": {
+//	    "<check name>": {
+//	      "status": "ok" | "warning" | "critical",
+//	      "error": "<error message, omitted when ok>",
+//	      "owner": "<owner of the check, see WithOwner>",
+//	      "informational": true,  // only for informational checks
+//	      "failed_open": true,  // only for timeouts of FailOpen checks
+//	      "paused": true,  // only for paused periodic checks
+//	      "forced": true,  // only for results forced with ForceResult
+//	      "skipped": true,  // only for checks skipped, see WithDependsOn
+//	      "muted": true,  // only for checks muted, see SetEnabled
+//	      "invocations": <number of times the check ran>,
+//	      "last_triggered_by": "<trigger of the last run, see WithTrigger>",
+//	      "age_ms": <age of a cached result in milliseconds>,
+//	      "duration_ms": <how long the check ran in milliseconds>,
+//	      "recent_transitions": <transitions within the flap detection window>,
+//	      "flapping": true  // only for checks flapping, see SetFlapDetection
+//	    }
+//	  }
+//	}
+//
+// The overall status is "unhealthy" if any check failed critically, or if
+// the health score dropped below the threshold set with SetScoreThreshold,
+// "degraded" if checks failed without making the registry unhealthy, such as
+// with SeverityWarning, and "healthy" otherwise, like the responses of
+// StatusHandler. Informational checks, fail-open timeouts and muted checks
+// are ignored in this regard. Consumers expecting different field names
+// should map Report onto their own types rather than rely on its encoding
+// being configurable.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckReport `json:"checks"`
+}
+
+// CheckReport is the outcome of a single check in a Report.
+type CheckReport struct {
+	// Status is the severity of the result of the check.
+	Status string `json:"status"`
+
+	// Error is the error message of a failing check.
+	Error string `json:"error,omitempty"`
+
+	// Owner is the team owning the check, as registered with WithOwner.
+	Owner string `json:"owner,omitempty"`
+
+	// Informational is set for checks registered with RegisterInformational,
+	// which do not affect the overall status.
+	Informational bool `json:"informational,omitempty"`
+
+	// FailedOpen is set when the check timed out under the FailOpen
+	// policy, which does not affect the overall status.
+	FailedOpen bool `json:"failed_open,omitempty"`
+
+	// Paused is set for periodic checks paused with PauseAll, whose result
+	// is the last one observed before the pause.
+	Paused bool `json:"paused,omitempty"`
+
+	// Forced is set when the result of the check was forced with
+	// ForceResult rather than produced by the check.
+	Forced bool `json:"forced,omitempty"`
+
+	// Skipped is set when the check was not run because a check it depends
+	// on failed critically, see WithDependsOn. Its error is then ErrSkipped.
+	Skipped bool `json:"skipped,omitempty"`
+
+	// Muted is set for checks muted with SetEnabled, which are not run
+	// and do not affect the overall status.
+	Muted bool `json:"muted,omitempty"`
+
+	// Invocations is the number of times the check ran since it was
+	// registered. Evaluations of periodic checks only read their last
+	// result, so only the runs of their goroutine are counted.
+	Invocations uint64 `json:"invocations,omitempty"`
+
+	// LastTriggeredBy is the trigger of the last run of the check.
+	LastTriggeredBy string `json:"last_triggered_by,omitempty"`
+
+	// AgeMs is the age in milliseconds of the result of checks serving
+	// cached results, such as periodic checks and
+	// StaleWhileRevalidateChecker.
+	AgeMs int64 `json:"age_ms,omitempty"`
+
+	// DurationMs is how long the check ran during the evaluation, in
+	// fractional milliseconds, to spot slow checks. It is measured around
+	// each check, also when checks run concurrently, and omitted for checks
+	// that did not run, such as muted checks.
+	DurationMs float64 `json:"duration_ms,omitempty"`
+
+	// RecentTransitions is the number of transitions of the check within
+	// the flap detection window of the registry.
+	RecentTransitions int `json:"recent_transitions,omitempty"`
+
+	// Flapping is set when RecentTransitions exceeds the flap detection
+	// threshold of the registry.
+	Flapping bool `json:"flapping,omitempty"`
+}
+
+// Report evaluates every check of the registry and returns their detailed
+// outcome.
+func (registry *Registry) Report() Report {
+	return registry.ReportContext(context.Background())
+}
+
+// ReportContext is like Report, attributing the evaluation to the trigger
+// carried by ctx, if any.
+func (registry *Registry) ReportContext(ctx context.Context) Report {
+	return registry.reportIf(ctx, nil)
+}
+
+// ReportChecks is like ReportContext, only evaluating the named checks, such
+// as those a service depends on, including those of included registries under
+// their prefixed names. Unknown names are ignored.
+func (registry *Registry) ReportChecks(ctx context.Context, names ...string) Report {
+	checks := make(map[*registeredCheck]bool, len(names))
+	for _, name := range names {
+		if rc := registry.lookup(name); rc != nil {
+			checks[rc] = true
+		}
+	}
+	return registry.reportIf(ctx, func(rc *registeredCheck) bool {
+		return checks[rc]
+	})
+}
+
+// reportIf returns the report of the checks of the registry for which include
+// returns true, or of every check if include is nil.
+func (registry *Registry) reportIf(ctx context.Context, include func(rc *registeredCheck) bool) Report {
+	report := Report{
+		Status: StatusHealthy,
+		Checks: make(map[string]CheckReport),
+	}
+
+	transitions, flapThreshold := registry.recentTransitions()
+	results := registry.evaluateIf(ctx, include)
+	registry.drain(results)
+	for name, r := range results {
+		severity := SeverityOf(r.err)
+		cr := CheckReport{
+			Status:          severity.String(),
+			Owner:           r.owner,
+			Informational:   r.informational,
+			FailedOpen:      r.failedOpen,
+			Paused:          r.paused,
+			Forced:          r.forced,
+			Skipped:         r.skipped,
+			Muted:           r.muted,
+			Invocations:     r.invocations,
+			LastTriggeredBy: r.lastTrigger,
+			AgeMs:           r.age.Milliseconds(),
+			DurationMs:      float64(r.duration) / float64(time.Millisecond),
+		}
+		cr.RecentTransitions = transitions[name]
+		cr.Flapping = cr.RecentTransitions > flapThreshold
+		if r.err != nil {
+			cr.Error = registry.errorMessage(r.err)
+		}
+		report.Checks[name] = cr
+		if r.err != nil && r.affectsHealth() {
+			// failures not making the registry unhealthy, such as
+			// warnings, degrade it
+			report.Status = StatusDegraded
+		}
+	}
+	if registry.unhealthy(results) {
+		report.Status = StatusUnhealthy
+	}
+
+	return report
+}
+
+// ReportHandler returns a handler serving the Report of registry as JSON to
+// GET requests, with a 503 status code when it is unhealthy and 200
+// otherwise, such as to spot the slow checks of a probe from their
+// durations. The handler is not mounted by default.
+func ReportHandler(registry *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveReport(w, r, registry, false)
+	})
+}
+
+// SummaryHandler serves the Report of the default registry as JSON to GET
+// requests, always with a 200 status code, for dashboards which should render
+// whatever the health of the service. Unlike StatusHandler, it is not meant
+// to gate traffic. init registers it at /debug/health/summary, which shadows
+// any group named "summary".
+func SummaryHandler(w http.ResponseWriter, r *http.Request) {
+	serveReport(w, r, DefaultRegistry, true)
+}
+
+// serveReport serves the report of registry, with a 200 status code if
+// alwaysOK is set or the report is not unhealthy, and a 503 otherwise.
+func serveReport(w http.ResponseWriter, r *http.Request, registry *Registry, alwaysOK bool) {
+	if r.Method != "GET" {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report := registry.ReportContext(r.Context())
+	status := http.StatusOK
+	if report.Status == StatusUnhealthy && !alwaysOK {
+		status = http.StatusServiceUnavailable
+	}
+	statusResponse(w, r, status, report)
+}
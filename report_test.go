@@ -0,0 +1,185 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestReport ensures that a report covers every check and serializes to the
+// documented schema.
+func TestReport(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("db", func() error {
+		return nil
+	})
+	registry.RegisterFunc("cache", func() error {
+		return &StatusError{Severity: SeverityWarning, Err: errors.New("slow")}
+	})
+
+	report := registry.Report()
+	if report.Status != StatusDegraded {
+		t.Errorf("unexpected status: %s", report.Status)
+	}
+
+	for name, cr := range report.Checks {
+		if cr.DurationMs <= 0 {
+			t.Errorf("expected the duration of %s to be measured", name)
+		}
+		// durations vary between runs
+		cr.DurationMs = 0
+		report.Checks[name] = cr
+	}
+
+	p, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("error serializing report: %v", err)
+	}
+	expected := `{"status":"degraded","checks":{` +
+		`"cache":{"status":"warning","error":"slow","invocations":1,"last_triggered_by":"probe"},` +
+		`"db":{"status":"ok","invocations":1,"last_triggered_by":"probe"}}}`
+	if string(p) != expected {
+		t.Errorf("unexpected serialized report: %s != %s", p, expected)
+	}
+
+	registry.RegisterFunc("queue", func() error {
+		return errors.New("unreachable")
+	})
+	if report := registry.Report(); report.Status != StatusUnhealthy {
+		t.Errorf("unexpected status with a critical failure: %s", report.Status)
+	}
+}
+
+// TestReportChecks ensures that a report of named checks only evaluates those
+// checks, including those of included registries.
+func TestReportChecks(t *testing.T) {
+	registry := NewRegistry()
+	child := NewRegistry()
+	registry.Include("child", child)
+	var evaluated int32
+	registry.RegisterFunc("db", func() error {
+		atomic.AddInt32(&evaluated, 1)
+		return nil
+	})
+	registry.RegisterFunc("cache", func() error {
+		atomic.AddInt32(&evaluated, 1)
+		return errors.New("down")
+	})
+	child.RegisterFunc("queue", func() error {
+		atomic.AddInt32(&evaluated, 1)
+		return nil
+	})
+
+	report := registry.ReportChecks(context.Background(), "db", "child/queue", "unknown")
+	if report.Status != StatusHealthy {
+		t.Errorf("unexpected status: %s", report.Status)
+	}
+	if len(report.Checks) != 2 {
+		t.Errorf("unexpected checks reported: %v", report.Checks)
+	}
+	if _, ok := report.Checks["child/queue"]; !ok {
+		t.Errorf("expected the included check to be reported: %v", report.Checks)
+	}
+	if n := atomic.LoadInt32(&evaluated); n != 2 {
+		t.Errorf("expected 2 checks to be evaluated, got %d", n)
+	}
+}
+
+// TestInformationalChecks ensures that informational checks are reported but
+// do not affect the overall health.
+func TestInformationalChecks(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterInformational("build_info", CheckFunc(func() error {
+		return errors.New("stale build")
+	}))
+
+	report := registry.Report()
+	if report.Status != StatusHealthy {
+		t.Errorf("unexpected status: %s", report.Status)
+	}
+	if cr := report.Checks["build_info"]; !cr.Informational || cr.Error != "stale build" {
+		t.Errorf("informational check was expected in the report: %+v", cr)
+	}
+
+	if status := registry.failingChecks(); len(status) != 0 {
+		t.Errorf("informational check was not expected to be part of the status: %v", status)
+	}
+}
+
+// TestOwner ensures that the owner of a check annotates its report and state
+// changes.
+func TestOwner(t *testing.T) {
+	registry := NewRegistry()
+	updater := NewStatusUpdater()
+	registry.Register("db", updater, WithOwner("storage-team"))
+	updater.Update(nil)
+	updater.Update(errors.New("down"))
+
+	report := registry.Report()
+	if report.Checks["db"].Owner != "storage-team" {
+		t.Errorf("unexpected owner in the report: %+v", report.Checks["db"])
+	}
+	if events := registry.RecentEvents(1); len(events) != 1 || events[0].Owner != "storage-team" {
+		t.Errorf("unexpected owner in the events: %+v", events)
+	}
+}
+
+// TestReportHandler ensures that the report handler serves the report, with
+// the duration of each check.
+func TestReportHandler(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("slow", func() error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+	registry.RegisterFunc("db", func() error { return errors.New("down") })
+
+	recorder := httptest.NewRecorder()
+	ReportHandler(registry).ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/health/report", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Did not get a 503, got %d", recorder.Code)
+	}
+
+	var report Report
+	if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+		t.Fatalf("error decoding report: %v", err)
+	}
+	if d := report.Checks["slow"].DurationMs; d < 10 {
+		t.Errorf("expected the slow check to take at least 10ms, got %vms", d)
+	}
+	if cr := report.Checks["db"]; cr.Status != "critical" || cr.Error != "down" {
+		t.Errorf("unexpected report of the failing check: %+v", cr)
+	}
+}
+
+// TestSummaryHandler ensures that the summary endpoint reports every check of
+// the default registry while always succeeding.
+func TestSummaryHandler(t *testing.T) {
+	// clear out existing checks.
+	DefaultRegistry = NewRegistry()
+	RegisterFunc("db", func() error { return errors.New("down") })
+	RegisterFunc("cache", func() error { return nil })
+
+	req := httptest.NewRequest("GET", "/debug/health/summary", nil)
+	if _, pattern := http.DefaultServeMux.Handler(req); pattern != "/debug/health/summary" {
+		t.Errorf("summary endpoint was expected to be mounted, got pattern %q", pattern)
+	}
+	recorder := httptest.NewRecorder()
+	SummaryHandler(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Did not get a 200, got %d", recorder.Code)
+	}
+
+	var report Report
+	if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+		t.Fatalf("error decoding report: %v", err)
+	}
+	if report.Status != StatusUnhealthy || len(report.Checks) != 2 {
+		t.Errorf("unexpected summary: %+v", report)
+	}
+}
@@ -0,0 +1,33 @@
+package health
+
+import (
+	"context"
+	"time"
+)
+
+// RetryChecker returns a Checker running check up to attempts times, waiting
+// delay between attempts, so that services failing transiently on the first
+// attempt do not raise false alarms. It passes on the first passing attempt
+// and reports the error of the last attempt otherwise. The remaining attempts
+// are abandoned once the deadline of the evaluation expires. An attempts
+// value below 1 is treated as 1. Every attempt runs check, even if it
+// implements Identifier, rather than reuse the result shared within the
+// evaluation, see Evaluate.
+func RetryChecker(check Checker, attempts int, delay time.Duration) Checker {
+	return CheckFuncContext(func(ctx context.Context) error {
+		var err error
+		for i := 0; ; i++ {
+			if err = evaluate(ctx, check); err == nil || i+1 >= attempts {
+				return err
+			}
+
+			t := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return err
+			case <-t.C:
+			}
+		}
+	})
+}
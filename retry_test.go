@@ -0,0 +1,69 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestRetryChecker ensures that a retry checker passes on the first passing
+// attempt and reports the last error otherwise.
+func TestRetryChecker(t *testing.T) {
+	calls := 0
+	flaky := CheckFunc(func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("attempt " + strconv.Itoa(calls))
+		}
+		return nil
+	})
+
+	if err := RetryChecker(flaky, 3, time.Millisecond).Check(); err != nil || calls != 3 {
+		t.Errorf("check was expected to pass on the third attempt, error:%v after %d calls", err, calls)
+	}
+
+	calls = 0
+	if err := RetryChecker(flaky, 2, time.Millisecond).Check(); err == nil || err.Error() != "attempt 2" || calls != 2 {
+		t.Errorf("the last error was expected after 2 attempts, error:%v after %d calls", err, calls)
+	}
+
+	calls = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := Evaluate(ctx, RetryChecker(flaky, 3, time.Hour))
+	if err == nil || err.Error() != "attempt 1" || calls != 1 {
+		t.Errorf("retries were expected to stop at the deadline, error:%v after %d calls", err, calls)
+	}
+}
+
+// flakyIdentifiedCheck is an Identifier failing on its first invocation.
+type flakyIdentifiedCheck struct {
+	calls *int
+}
+
+func (c flakyIdentifiedCheck) Check() error {
+	*c.calls++
+	if *c.calls == 1 {
+		return errors.New("attempt 1")
+	}
+	return nil
+}
+
+func (c flakyIdentifiedCheck) Identity() string {
+	return "flaky"
+}
+
+// TestRetryCheckerIdentifier ensures that the attempts of a retry checker
+// evaluated by a registry run an Identifier check again rather than reuse its
+// shared result.
+func TestRetryCheckerIdentifier(t *testing.T) {
+	registry := NewRegistry()
+	calls := 0
+	registry.Register("flaky", RetryChecker(flakyIdentifiedCheck{calls: &calls}, 3, time.Millisecond))
+
+	if failing := registry.failingChecks(); len(failing) != 0 || calls != 2 {
+		t.Errorf("check was expected to pass on the second attempt: %v after %d calls", failing, calls)
+	}
+}
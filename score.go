@@ -0,0 +1,80 @@
+package health
+
+import (
+	"context"
+	"strconv"
+)
+
+// WithWeight sets the weight of the check in the health score of the
+// registry, 1 by default. It panics if weight is negative.
+func WithWeight(weight float64) CheckOption {
+	if weight < 0 {
+		panic("check weight must not be negative: " + strconv.FormatFloat(weight, 'g', -1, 64))
+	}
+	return func(rc *registeredCheck) {
+		rc.weight = weight
+	}
+}
+
+// Score evaluates every check of the registry and returns its health score,
+// from 0 when every check fails critically to 1 when none does. Each check
+// failing critically subtracts its share of the total weight of the checks
+// affecting the overall health, see WithWeight. The score of a registry
+// without such checks is 1.
+func (registry *Registry) Score() float64 {
+	return score(registry.evaluate(context.Background()))
+}
+
+// Score returns the health score of the default registry.
+func Score() float64 {
+	return DefaultRegistry.Score()
+}
+
+// SetScoreThreshold makes the registry unhealthy only once its health score
+// drops below threshold, rather than as soon as any check fails critically,
+// so that outages of minor dependencies are tolerated. A threshold of 0
+// restores the default behavior.
+func (registry *Registry) SetScoreThreshold(threshold float64) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.scoreThreshold = threshold
+}
+
+// SetScoreThreshold sets the score threshold of the default registry.
+func SetScoreThreshold(threshold float64) {
+	DefaultRegistry.SetScoreThreshold(threshold)
+}
+
+// unhealthy reports whether the results make the registry unhealthy, per its
+// score threshold if one is set, or include the failure of draining.
+func (registry *Registry) unhealthy(results map[string]result) bool {
+	registry.mu.RLock()
+	threshold := registry.scoreThreshold
+	registry.mu.RUnlock()
+
+	if results[drainingCheck].draining {
+		return true
+	}
+	if threshold > 0 {
+		return score(results) < threshold
+	}
+	return failedCritically(results)
+}
+
+// score returns the health score of the results.
+func score(results map[string]result) float64 {
+	var total, failed float64
+	for _, r := range results {
+		if !r.affectsHealth() {
+			continue
+		}
+		total += r.weight
+		if SeverityOf(r.err) == SeverityCritical {
+			failed += r.weight
+		}
+	}
+	if total == 0 {
+		return 1
+	}
+	return 1 - failed/total
+}
@@ -0,0 +1,64 @@
+package health
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestScore ensures that the health score accounts for the weights of the
+// checks failing critically.
+func TestScore(t *testing.T) {
+	registry := NewRegistry()
+	if score := registry.Score(); score != 1 {
+		t.Errorf("unexpected score of an empty registry: %v", score)
+	}
+
+	registry.RegisterFunc("db", func() error { return nil }, WithWeight(3))
+	registry.RegisterFunc("cache", func() error { return errors.New("down") })
+	registry.RegisterFunc("search", func() error {
+		return &StatusError{Severity: SeverityWarning, Err: errors.New("slow")}
+	}, WithWeight(2))
+	registry.RegisterInformational("docs", CheckFunc(func() error { return errors.New("down") }))
+
+	// cache subtracts 1 out of a total weight of 6
+	if score := registry.Score(); score != 1-1.0/6 {
+		t.Errorf("unexpected score: %v", score)
+	}
+}
+
+// TestScoreThreshold ensures that a registry with a score threshold is only
+// unhealthy once its score drops below the threshold.
+func TestScoreThreshold(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetScoreThreshold(0.5)
+	failing := errors.New("down")
+	registry.RegisterFunc("db", func() error { return failing }, WithWeight(2))
+	registry.RegisterFunc("cache", func() error { return errors.New("down") })
+
+	handler := NewStatusHandler(registry)
+	for _, tc := range []struct {
+		dbErr  error
+		status int
+		report string
+	}{
+		{nil, http.StatusOK, StatusDegraded},
+		{failing, http.StatusServiceUnavailable, StatusUnhealthy},
+	} {
+		failing = tc.dbErr
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+		if recorder.Code != tc.status {
+			t.Errorf("expected %d with db error %v, got %d", tc.status, tc.dbErr, recorder.Code)
+		}
+		if status := registry.Report().Status; status != tc.report {
+			t.Errorf("expected report status %s with db error %v, got %s", tc.report, tc.dbErr, status)
+		}
+		recorder = httptest.NewRecorder()
+		ReportHandler(registry).ServeHTTP(recorder, httptest.NewRequest("GET", "/report", nil))
+		if recorder.Code != tc.status {
+			t.Errorf("expected report handler %d with db error %v, got %d", tc.status, tc.dbErr, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Severity is the impact of a check result on the health of the service.
+type Severity int
+
+const (
+	// SeverityOK is the severity of a passing check.
+	SeverityOK Severity = iota
+
+	// SeverityWarning is the severity of a failure that degrades the
+	// service without making it unhealthy.
+	SeverityWarning
+
+	// SeverityCritical is the severity of a failure that makes the service
+	// unhealthy.
+	SeverityCritical
+)
+
+// String returns the lowercase name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityOK:
+		return "ok"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	}
+	return "unknown"
+}
+
+// StatusError is an error returned by a check carrying the severity of the
+// failure.
+type StatusError struct {
+	Severity Severity
+	Err      error
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// SeverityOf returns the severity of a check result: SeverityOK for a nil
+// error, the severity of the first StatusError in the chain of err, or
+// SeverityCritical for any other error.
+func SeverityOf(err error) Severity {
+	if err == nil {
+		return SeverityOK
+	}
+
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Severity
+	}
+
+	return SeverityCritical
+}
+
+// Warnf returns an error formatted according to format, as with fmt.Errorf,
+// with SeverityWarning. A check returning it reports a concern, such as a
+// growing replication lag, which degrades the service without making it
+// unhealthy.
+func Warnf(format string, args ...interface{}) error {
+	return &StatusError{Severity: SeverityWarning, Err: fmt.Errorf(format, args...)}
+}
@@ -0,0 +1,73 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultStalenessMultiplier is the staleness multiplier of new registries,
+// see SetStalenessMultiplier.
+const defaultStalenessMultiplier = 3
+
+// ErrStale is reported for periodic checks whose goroutine did not start
+// running the check for longer than allowed by the staleness multiplier of the
+// registry, see SetStalenessMultiplier.
+var ErrStale = errors.New("periodic check is stale")
+
+// staleGuarded is implemented by checks running in the background, which may
+// silently stop doing so.
+type staleGuarded interface {
+	// stale returns ErrStale, wrapped, if the check did not start running
+	// for longer than multiplier times its expected interval between runs,
+	// or than the duration of its last run if longer.
+	stale(multiplier float64) error
+}
+
+// SetStalenessMultiplier makes the periodic checks of the registry, such as
+// those created by PeriodicChecker, fail with ErrStale once their goroutine
+// has not started running the check for multiplier times their period, rather
+// than keep reporting their last result forever, e.g. after their check
+// deadlocked. A run in progress counts from when it started, and checks whose
+// last run took longer than their period are allowed multiplier times that
+// duration instead, so that slow checks are not stale while their runs keep
+// taking about as long; only their first run is bounded by their period.
+// Stopped and paused periodic checks, as well as driven checks, are never
+// stale. The multiplier defaults to 3; 0 disables the guard.
+func (registry *Registry) SetStalenessMultiplier(multiplier float64) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.stalenessMultiplier = multiplier
+}
+
+// SetStalenessMultiplier sets the staleness multiplier of the default
+// registry.
+func SetStalenessMultiplier(multiplier float64) {
+	DefaultRegistry.SetStalenessMultiplier(multiplier)
+}
+
+// stale implements the staleGuarded interface.
+func (pc *periodicChecker) stale(multiplier float64) error {
+	if pc.maxGap <= 0 || multiplier <= 0 || pc.isPaused() {
+		return nil
+	}
+	select {
+	case <-pc.done:
+		return nil
+	default:
+	}
+
+	pc.mu.Lock()
+	last, interval := pc.lastStart, pc.maxGap
+	if pc.lastDuration > interval {
+		interval = pc.lastDuration
+	}
+	pc.mu.Unlock()
+	if last.IsZero() {
+		last = pc.started
+	}
+	if age := time.Since(last); age > time.Duration(multiplier*float64(interval)) {
+		return fmt.Errorf("%w: last started running %v ago", ErrStale, age.Round(time.Millisecond))
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package health
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestStalePeriodicChecker ensures that a periodic check whose goroutine
+// stopped running it fails by default, unless it was stopped on purpose.
+func TestStalePeriodicChecker(t *testing.T) {
+	registry := NewRegistry()
+	block := make(chan struct{})
+	defer close(block)
+	runs := 0
+	checker := PeriodicChecker(CheckFunc(func() error {
+		runs++
+		if runs > 1 {
+			<-block // hangs the goroutine after a passing run
+		}
+		return nil
+	}), time.Millisecond)
+	defer checker.Stop()
+	registry.Register("hung", checker)
+
+	for i := 0; ; i++ {
+		err := registry.CheckStatus()["hung"].Err
+		if errors.Is(err, ErrStale) {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("hung periodic check was expected to become stale, error:%v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	registry.SetStalenessMultiplier(0)
+	if err := registry.CheckStatus()["hung"].Err; err != nil {
+		t.Errorf("staleness was not expected to be checked once disabled, error:%v", err)
+	}
+
+	stopped := PeriodicChecker(CheckFunc(func() error { return nil }), time.Millisecond)
+	stopped.Stop()
+	registry.SetStalenessMultiplier(1)
+	registry.Register("stopped", stopped)
+	time.Sleep(5 * time.Millisecond)
+	if err := registry.CheckStatus()["stopped"].Err; err != nil {
+		t.Errorf("stopped periodic check was not expected to become stale, error:%v", err)
+	}
+}
+
+// TestSlowPeriodicCheckerNotStale ensures that a periodic check running for
+// longer than its period is not stale by default once its duration is known,
+// nor while its runs keep starting within the staleness multiplier.
+func TestSlowPeriodicCheckerNotStale(t *testing.T) {
+	registry := NewRegistry()
+	checker := PeriodicChecker(CheckFunc(func() error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}), time.Millisecond)
+	defer checker.Stop()
+	registry.Register("slow", checker)
+
+	pc := checker.(*periodicChecker)
+	for i := 0; ; i++ {
+		pc.mu.Lock()
+		ran := !pc.lastRun.IsZero()
+		pc.mu.Unlock()
+		if ran {
+			break
+		}
+		if i == 100 {
+			t.Fatal("slow periodic check was expected to complete a run")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 5; i++ {
+		if err := registry.CheckStatus()["slow"].Err; err != nil {
+			t.Errorf("slow periodic check was not expected to fail by default, error:%v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	registry.SetStalenessMultiplier(100)
+	for i := 0; i < 5; i++ {
+		if err := registry.CheckStatus()["slow"].Err; err != nil {
+			t.Errorf("slow periodic check was not expected to be stale, error:%v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
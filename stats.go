@@ -0,0 +1,27 @@
+package health
+
+// EvaluationStats describes the checks being evaluated by a registry at a
+// point in time. A steadily high number of queued checks indicates that checks
+// are too slow for the rate at which the registry is evaluated.
+type EvaluationStats struct {
+	// InFlight is the number of checks currently running, including those
+	// abandoned after their timeout expired but which did not return yet.
+	InFlight int
+
+	// Queued is the number of checks waiting for their turn to run in the
+	// evaluations in progress, such as checks waiting for a goroutine of
+	// the Pool of the registry.
+	Queued int
+}
+
+// EvaluationStats returns the current evaluation statistics of the registry.
+func (registry *Registry) EvaluationStats() EvaluationStats {
+	registry.inflightMu.Lock()
+	inflight := len(registry.inflight)
+	registry.inflightMu.Unlock()
+
+	return EvaluationStats{
+		InFlight: inflight,
+		Queued:   int(registry.queued.Load()),
+	}
+}
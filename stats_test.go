@@ -0,0 +1,50 @@
+package health
+
+import (
+	"testing"
+	"time"
+)
+
+// TestEvaluationStats ensures that running and waiting checks are accounted
+// for while an evaluation is in progress.
+func TestEvaluationStats(t *testing.T) {
+	// a single goroutine makes the second check wait for the first
+	pool := NewPool(1)
+	defer pool.Close()
+	registry := NewRegistry()
+	registry.SetPool(pool)
+
+	started := make(chan struct{}, 2)
+	block := make(chan struct{})
+	blocking := func() error {
+		started <- struct{}{}
+		<-block
+		return nil
+	}
+	registry.RegisterFunc("first", blocking)
+	registry.RegisterFunc("second", blocking)
+
+	if stats := registry.EvaluationStats(); stats != (EvaluationStats{}) {
+		t.Fatalf("unexpected stats before evaluation: %+v", stats)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		registry.failingChecks()
+	}()
+
+	<-started
+	if stats := registry.EvaluationStats(); stats != (EvaluationStats{InFlight: 1, Queued: 1}) {
+		t.Errorf("unexpected stats during evaluation: %+v", stats)
+	}
+
+	close(block)
+	<-done
+	for i := 0; registry.EvaluationStats() != (EvaluationStats{}); i++ {
+		if i == 100 {
+			t.Fatalf("unexpected stats after evaluation: %+v", registry.EvaluationStats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
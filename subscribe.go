@@ -0,0 +1,77 @@
+package health
+
+import "sync"
+
+// subscriptionBuffer is the number of transitions buffered for each
+// subscriber of a registry.
+const subscriptionBuffer = 16
+
+// subscription is a channel returned by Subscribe.
+type subscription struct {
+	mu     sync.Mutex
+	ch     chan CheckResult
+	closed bool
+}
+
+// send delivers result to the subscriber, dropping the oldest buffered result
+// if the subscriber fell behind.
+func (s *subscription) send(result CheckResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	for {
+		select {
+		case s.ch <- result:
+			return
+		default:
+		}
+		select {
+		case <-s.ch:
+		default:
+		}
+	}
+}
+
+// close closes the channel of the subscription.
+func (s *subscription) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// Subscribe returns a channel receiving the result of a check every time it
+// becomes healthy or unhealthy, as reported by OnStateChange, such as to stop
+// accepting new work in-process without polling the status handlers. The
+// channel is buffered: once a slow subscriber falls 16 transitions behind,
+// the oldest are dropped rather than blocking the checks. The returned func
+// unsubscribes and closes the channel; it is idempotent.
+func (registry *Registry) Subscribe() (<-chan CheckResult, func()) {
+	s := &subscription{ch: make(chan CheckResult, subscriptionBuffer)}
+
+	registry.eventsMu.Lock()
+	if registry.subscriptions == nil {
+		registry.subscriptions = make(map[*subscription]struct{})
+	}
+	registry.subscriptions[s] = struct{}{}
+	registry.eventsMu.Unlock()
+
+	return s.ch, func() {
+		registry.eventsMu.Lock()
+		delete(registry.subscriptions, s)
+		registry.eventsMu.Unlock()
+		s.close()
+	}
+}
+
+// Subscribe returns a channel receiving the transitions of the checks of the
+// default registry.
+func Subscribe() (<-chan CheckResult, func()) {
+	return DefaultRegistry.Subscribe()
+}
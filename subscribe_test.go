@@ -0,0 +1,43 @@
+package health
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// TestSubscribe ensures that subscribers receive the transitions of the
+// checks, the oldest being dropped once they fall behind.
+func TestSubscribe(t *testing.T) {
+	registry := NewRegistry()
+	updater := NewStatusUpdater()
+	registry.Register("db", updater)
+	results, unsubscribe := registry.Subscribe()
+
+	updater.Update(nil)
+	updater.Update(errors.New("down"))
+	if r := <-results; r.Name != "db" || r.Err == nil || r.Timestamp.IsZero() {
+		t.Errorf("unexpected transition: %+v", r)
+	}
+
+	// 20 transitions, of which the first 4 are dropped
+	for i := 0; i < 10; i++ {
+		updater.Update(nil)
+		updater.Update(errors.New("down " + strconv.Itoa(i)))
+	}
+	if n := len(results); n != subscriptionBuffer {
+		t.Fatalf("expected %d buffered transitions, got %d", subscriptionBuffer, n)
+	}
+	if r := <-results; r.Err != nil {
+		t.Errorf("the oldest transitions were expected to be dropped, got %+v", r)
+	}
+	if r := <-results; r.Err == nil || r.Err.Error() != "down 2" {
+		t.Errorf("the oldest transitions were expected to be dropped, got %+v", r)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	updater.Update(nil)
+	for range results {
+	}
+}
@@ -0,0 +1,73 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// TraceHandler returns a handler that runs every check of the registry anew,
+// one after the other in name order, and streams a line describing each
+// result and how long it took as soon as the check completes. This shows in
+// real time which check is slow. The stack trace of checks that panic is
+// included in the output.
+//
+// Because it forces a full re-evaluation, requests are only served when they
+// carry a true "trace" query parameter (e.g. "?trace=1") and when authorized
+// returns true for them: as the output includes stack traces, a nil
+// authorized denies every request. The handler is not mounted by default.
+func TraceHandler(registry *Registry, authorized func(*http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			http.NotFound(w, r)
+			return
+		}
+		if authorized == nil || !authorized(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if trace, _ := strconv.ParseBool(r.URL.Query().Get("trace")); !trace {
+			http.Error(w, "tracing re-evaluates every check: set trace=1 to proceed", http.StatusBadRequest)
+			return
+		}
+
+		registry.mu.RLock()
+		names := make([]string, 0, len(registry.registeredChecks))
+		checks := make(map[string]*registeredCheck, len(registry.registeredChecks))
+		for name, rc := range registry.registeredChecks {
+			names = append(names, name)
+			checks[name] = rc
+		}
+		timeout := registry.defaultTimeout
+		registry.mu.RUnlock()
+		sort.Strings(names)
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		flusher, _ := w.(http.Flusher)
+		ctx := newEvaluation(WithTrigger(r.Context(), TriggerTrace))
+		registry.queued.Add(int64(len(names)))
+		for _, name := range names {
+			registry.queued.Add(-1)
+			rc := checks[name]
+			start := time.Now()
+			err := registry.runCheck(ctx, name, rc, rc.timeoutOr(timeout))
+			elapsed := time.Since(start)
+
+			var pe *panicError
+			if errors.As(err, &pe) {
+				fmt.Fprintf(w, "%s failed in %v: %s\n%s\n", name, elapsed, registry.errorMessage(err), pe.stack)
+			} else if err != nil {
+				fmt.Fprintf(w, "%s failed in %v: %s\n", name, elapsed, registry.errorMessage(err))
+			} else {
+				fmt.Fprintf(w, "%s ok in %v\n", name, elapsed)
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestTraceHandler ensures that the trace handler is guarded by its query
+// flag and authorization function, and reports every check in name order.
+func TestTraceHandler(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("b_failing", func() error {
+		return errors.New("boom")
+	})
+	registry.RegisterFunc("a_passing", func() error {
+		return nil
+	})
+
+	handler := TraceHandler(registry, func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "secret"
+	})
+
+	serve := func(target, auth string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Authorization", auth)
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	if recorder := serve("/debug/health/trace?trace=1", "wrong"); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("unexpected response code without authorization: %d", recorder.Code)
+	}
+
+	recorder := httptest.NewRecorder()
+	TraceHandler(registry, nil).ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/health/trace?trace=1", nil))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("unexpected response code without authorization function: %d", recorder.Code)
+	}
+
+	if recorder := serve("/debug/health/trace", "secret"); recorder.Code != http.StatusBadRequest {
+		t.Errorf("unexpected response code without trace flag: %d", recorder.Code)
+	}
+
+	recorder = serve("/debug/health/trace?trace=1", "secret")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected response code: %d", recorder.Code)
+	}
+
+	lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected trace: %q", recorder.Body.String())
+	}
+	if !strings.HasPrefix(lines[0], "a_passing ok in ") {
+		t.Errorf("unexpected first trace line: %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "b_failing failed in ") || !strings.HasSuffix(lines[1], ": boom") {
+		t.Errorf("unexpected second trace line: %q", lines[1])
+	}
+}
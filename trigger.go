@@ -0,0 +1,59 @@
+package health
+
+import "context"
+
+// Triggers attributed to the runs of checks by this package.
+const (
+	// TriggerProbe is the trigger of evaluations of a registry, such as
+	// those made by the status handlers, unless set with WithTrigger.
+	TriggerProbe = "probe"
+
+	// TriggerPeriodic is the trigger of the runs of periodic checks.
+	TriggerPeriodic = "periodic"
+
+	// TriggerTrace is the trigger of evaluations made by TraceHandler.
+	TriggerTrace = "trace"
+)
+
+// triggerKey is the context key of the trigger of an evaluation.
+type triggerKey struct{}
+
+// WithTrigger returns a copy of ctx attributing the evaluations made with it
+// to trigger, such as "recheck", helping to diagnose what causes checks to
+// run. The trigger of the last run of each check is part of the Report.
+func WithTrigger(ctx context.Context, trigger string) context.Context {
+	return context.WithValue(ctx, triggerKey{}, trigger)
+}
+
+// triggerOf returns the trigger carried by ctx, TriggerProbe by default.
+func triggerOf(ctx context.Context) string {
+	if trigger, ok := ctx.Value(triggerKey{}).(string); ok {
+		return trigger
+	}
+	return TriggerProbe
+}
+
+// runNotifier is implemented by checks that run on their own, such as
+// periodic checks, whose invocations are counted as they run rather than when
+// the registry reads their last result.
+type runNotifier interface {
+	notifyRuns(listener func())
+}
+
+// recordInvocation counts a run of the check attributed to trigger.
+func (rc *registeredCheck) recordInvocation(trigger string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.invocations++
+	rc.lastTrigger = trigger
+}
+
+// invocationStats returns the number of runs of the check and the trigger of
+// the last one.
+func (rc *registeredCheck) invocationStats() (uint64, string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	return rc.invocations, rc.lastTrigger
+}
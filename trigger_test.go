@@ -0,0 +1,36 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestInvocations ensures that the runs of checks are counted and attributed
+// to their trigger.
+func TestInvocations(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("direct", func() error {
+		return nil
+	})
+	registry.Register("periodic", PeriodicChecker(CheckFunc(func() error {
+		return nil
+	}), time.Millisecond))
+
+	registry.Report()
+	report := registry.ReportContext(WithTrigger(context.Background(), "recheck"))
+
+	if cr := report.Checks["direct"]; cr.Invocations != 2 || cr.LastTriggeredBy != "recheck" {
+		t.Errorf("unexpected invocations of direct check: %+v", cr)
+	}
+
+	for i := 0; registry.Report().Checks["periodic"].Invocations == 0; i++ {
+		if i == 100 {
+			t.Fatalf("periodic check was expected to run")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if cr := registry.Report().Checks["periodic"]; cr.LastTriggeredBy != TriggerPeriodic {
+		t.Errorf("unexpected trigger of periodic check: %+v", cr)
+	}
+}